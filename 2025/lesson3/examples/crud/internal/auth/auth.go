@@ -0,0 +1,111 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"crud-example/internal/logger"
+	"crud-example/internal/response"
+)
+
+// contextKey - собственный тип для ключей контекста
+// Строковые ключи ("userID") могут случайно совпасть с ключами других пакетов
+type contextKey struct{}
+
+// userIDKey - ключ, под которым в контексте лежит ID пользователя
+var userIDKey = contextKey{}
+
+// Claims - содержимое JWT токена
+type Claims struct {
+	UserID int64 `json:"user_id"`
+	jwt.RegisteredClaims
+}
+
+// WithUserID - кладет ID пользователя в контекст
+func WithUserID(ctx context.Context, userID int64) context.Context {
+	return context.WithValue(ctx, userIDKey, userID)
+}
+
+// UserIDFromContext - достает ID пользователя из контекста
+// ok == false, если запрос не прошел через Middleware
+func UserIDFromContext(ctx context.Context) (int64, bool) {
+	userID, ok := ctx.Value(userIDKey).(int64)
+	return userID, ok
+}
+
+// IssueToken - создает подписанный JWT токен для пользователя
+func IssueToken(secret []byte, userID int64, ttl time.Duration) (string, error) {
+	now := time.Now()
+	claims := Claims{
+		UserID: userID,
+		RegisteredClaims: jwt.RegisteredClaims{
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+		},
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString(secret)
+}
+
+// ParseToken - проверяет подпись и срок действия токена, возвращает ID пользователя
+func ParseToken(secret []byte, tokenString string) (int64, error) {
+	claims := &Claims{}
+
+	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		// Проверка метода подписи: иначе можно подсунуть токен с alg=none
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		return secret, nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	if !token.Valid {
+		return 0, fmt.Errorf("invalid token")
+	}
+
+	if claims.UserID <= 0 {
+		return 0, fmt.Errorf("token has no user_id")
+	}
+
+	return claims.UserID, nil
+}
+
+// Middleware - проверяет заголовок "Authorization: Bearer <token>"
+// и кладет ID пользователя в контекст запроса
+func Middleware(secret []byte) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			authHeader := r.Header.Get("Authorization")
+			if authHeader == "" {
+				response.Error(w, http.StatusUnauthorized, response.CodeUnauthorized, "missing authorization header")
+				return
+			}
+
+			// Формат: "Bearer TOKEN"
+			tokenString, ok := strings.CutPrefix(authHeader, "Bearer ")
+			if !ok || tokenString == "" {
+				response.Error(w, http.StatusUnauthorized, response.CodeUnauthorized, "invalid authorization header format")
+				return
+			}
+
+			userID, err := ParseToken(secret, tokenString)
+			if err != nil {
+				response.Error(w, http.StatusUnauthorized, response.CodeUnauthorized, "invalid token")
+				return
+			}
+
+			// user_id попадет во все записи лога этого запроса
+			ctx := logger.With(WithUserID(r.Context(), userID), "user_id", userID)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
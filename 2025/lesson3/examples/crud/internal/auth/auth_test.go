@@ -0,0 +1,148 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"crud-example/internal/response"
+)
+
+var testSecret = []byte("0123456789abcdef0123456789abcdef")
+
+func signToken(t *testing.T, method jwt.SigningMethod, key any, claims jwt.Claims) string {
+	t.Helper()
+
+	token, err := jwt.NewWithClaims(method, claims).SignedString(key)
+	if err != nil {
+		t.Fatalf("sign %s token: %v", method.Alg(), err)
+	}
+	return token
+}
+
+func TestParseToken(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now()
+	valid := func(userID int64) *Claims {
+		return &Claims{
+			UserID: userID,
+			RegisteredClaims: jwt.RegisteredClaims{
+				IssuedAt:  jwt.NewNumericDate(now),
+				ExpiresAt: jwt.NewNumericDate(now.Add(time.Hour)),
+			},
+		}
+	}
+	expired := valid(1)
+	expired.ExpiresAt = jwt.NewNumericDate(now.Add(-time.Minute))
+
+	issued, err := IssueToken(testSecret, 7, time.Hour)
+	if err != nil {
+		t.Fatalf("IssueToken: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		token   string
+		want    int64
+		wantErr bool
+	}{
+		{name: "issued by IssueToken", token: issued, want: 7},
+		{name: "valid HS256", token: signToken(t, jwt.SigningMethodHS256, testSecret, valid(42)), want: 42},
+		{name: "alg none", token: signToken(t, jwt.SigningMethodNone, jwt.UnsafeAllowNoneSignatureType, valid(1)), wantErr: true},
+		{name: "RS256", token: signToken(t, jwt.SigningMethodRS256, rsaKey, valid(1)), wantErr: true},
+		{name: "expired", token: signToken(t, jwt.SigningMethodHS256, testSecret, expired), wantErr: true},
+		{name: "missing user_id", token: signToken(t, jwt.SigningMethodHS256, testSecret, jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(now.Add(time.Hour)),
+		}), wantErr: true},
+		{name: "zero user_id", token: signToken(t, jwt.SigningMethodHS256, testSecret, valid(0)), wantErr: true},
+		{name: "negative user_id", token: signToken(t, jwt.SigningMethodHS256, testSecret, valid(-1)), wantErr: true},
+		{name: "bad signature", token: signToken(t, jwt.SigningMethodHS256, []byte("another-secret-another-secret-00"), valid(1)), wantErr: true},
+		{name: "not a JWT", token: "not-a-token", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseToken(testSecret, tt.token)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("ParseToken() = %d, want error", got)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("ParseToken() = %d, %v; want %d", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	token, err := IssueToken(testSecret, 42, time.Hour)
+	if err != nil {
+		t.Fatalf("IssueToken: %v", err)
+	}
+
+	var gotUserID int64
+	var called bool
+	h := Middleware(testSecret)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+		gotUserID, _ = UserIDFromContext(r.Context())
+	}))
+
+	tests := []struct {
+		name   string
+		header string // "" - заголовка нет
+	}{
+		{name: "missing header"},
+		{name: "basic auth", header: "Basic dXNlcjpwYXNz"},
+		{name: "bearer without token", header: "Bearer "},
+		{name: "invalid token", header: "Bearer " + token + "x"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			called = false
+			req := httptest.NewRequest(http.MethodGet, "/todos", nil)
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+			rec := httptest.NewRecorder()
+
+			h.ServeHTTP(rec, req)
+
+			if called {
+				t.Error("handler called without valid token")
+			}
+			if rec.Code != http.StatusUnauthorized {
+				t.Errorf("status = %d, want 401", rec.Code)
+			}
+
+			var body response.ErrorResponse
+			if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil || body.Error.Code != response.CodeUnauthorized {
+				t.Errorf("body = %s, want error envelope with code %q", rec.Body, response.CodeUnauthorized)
+			}
+		})
+	}
+
+	t.Run("valid token", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/todos", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		rec := httptest.NewRecorder()
+
+		h.ServeHTTP(rec, req)
+
+		if !called || gotUserID != 42 {
+			t.Errorf("handler called = %v with user %d, want user 42", called, gotUserID)
+		}
+	})
+}
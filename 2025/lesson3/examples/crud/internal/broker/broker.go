@@ -0,0 +1,165 @@
+// Package broker - рассылка изменений задач подписчикам внутри процесса (для SSE)
+//
+// События истории (model.TodoEvent) публикует notify.Listener, получивший их через
+// LISTEN/NOTIFY, или сам TodoService после COMMIT (одна реплика), а каждый
+// открытый GET /todos/stream подписан на события своего пользователя. Последние
+// события хранятся в кольцевом буфере: переподключившийся клиент присылает
+// Last-Event-ID и получает то, что пропустил
+package broker
+
+import (
+	"sync"
+
+	"crud-example/internal/model"
+)
+
+// DefaultReplaySize - сколько последних событий хранится для повтора по Last-Event-ID
+const DefaultReplaySize = 1000
+
+// subscriptionBuffer - сколько событий может ждать отправки одному подписчику
+// Если клиент не успевает их забирать, подписка закрывается: он переподключится
+// с Last-Event-ID и получит пропущенное из буфера
+const subscriptionBuffer = 64
+
+// Broker - рассылает события подписчикам и хранит последние из них
+// ID событий - ID записей todo_events: они общие для всех реплик сервера
+type Broker struct {
+	mu         sync.Mutex
+	replay     []*model.TodoEvent // последние события в порядке публикации
+	replaySize int
+	subs       map[*Subscription]struct{}
+	closed     bool
+}
+
+// New - создает брокер, который хранит replaySize последних событий
+func New(replaySize int) *Broker {
+	if replaySize <= 0 {
+		replaySize = DefaultReplaySize
+	}
+
+	return &Broker{
+		replay:     make([]*model.TodoEvent, 0, replaySize),
+		replaySize: replaySize,
+		subs:       make(map[*Subscription]struct{}),
+	}
+}
+
+// Subscription - подписка на события одного пользователя
+type Subscription struct {
+	C <-chan *model.TodoEvent // закрывается при Close, Broker.Close или если подписчик отстал
+
+	ch     chan *model.TodoEvent
+	userID int64
+	broker *Broker
+}
+
+// Publish - сохраняет событие в буфере и отправляет его подписчикам владельца задачи
+// Не блокируется: отставшие подписчики отключаются
+func (b *Broker) Publish(event *model.TodoEvent) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return
+	}
+
+	if len(b.replay) == b.replaySize {
+		// Сдвигаем окно; append ниже время от времени перевыделит массив,
+		// и старые события освободятся
+		b.replay = b.replay[1:]
+	}
+	b.replay = append(b.replay, event)
+
+	for sub := range b.subs {
+		if sub.userID != event.ActorID {
+			continue
+		}
+
+		select {
+		case sub.ch <- event:
+		default:
+			b.remove(sub)
+		}
+	}
+}
+
+// Subscribe - подписывает на события пользователя userID
+// lastEventID - ID последнего события, которое клиент уже получил (0 - новое подключение).
+// replay - события пользователя после lastEventID; ok == false, если lastEventID
+// уже вытеснен из буфера (или неизвестен): клиенту нужно перечитать задачи целиком
+func (b *Broker) Subscribe(userID, lastEventID int64) (sub *Subscription, replay []*model.TodoEvent, ok bool) {
+	ch := make(chan *model.TodoEvent, subscriptionBuffer)
+	sub = &Subscription{C: ch, ch: ch, userID: userID, broker: b}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	// Подписка и чтение буфера под одной блокировкой: между ними ничего не потеряется
+	if b.closed {
+		close(ch)
+		return sub, nil, true
+	}
+	b.subs[sub] = struct{}{}
+
+	if lastEventID == 0 {
+		return sub, nil, true
+	}
+
+	// Ищем по позиции, а не по сравнению ID: события разных транзакций
+	// могут прийти не в порядке возрастания ID
+	for i := len(b.replay) - 1; i >= 0; i-- {
+		if b.replay[i].ID != lastEventID {
+			continue
+		}
+		for _, event := range b.replay[i+1:] {
+			if event.ActorID == userID {
+				replay = append(replay, event)
+			}
+		}
+		return sub, replay, true
+	}
+
+	return sub, nil, false
+}
+
+// Close - отписывает; повторный вызов ничего не делает
+func (s *Subscription) Close() {
+	s.broker.mu.Lock()
+	defer s.broker.mu.Unlock()
+
+	s.broker.remove(s)
+}
+
+// remove - удаляет подписку и закрывает ее канал; вызывается под b.mu
+func (b *Broker) remove(sub *Subscription) {
+	if _, ok := b.subs[sub]; ok {
+		delete(b.subs, sub)
+		close(sub.ch)
+	}
+}
+
+// Reset - забывает буфер повтора и закрывает текущие подписки
+// Вызывается, когда часть событий могла пройти мимо брокера (например, слушатель
+// LISTEN/NOTIFY переподключался): клиенты переподключатся, не найдут свой
+// Last-Event-ID и получат reset вместо потока с дырой
+func (b *Broker) Reset() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.replay = make([]*model.TodoEvent, 0, b.replaySize)
+	for sub := range b.subs {
+		b.remove(sub)
+	}
+}
+
+// Close - закрывает все подписки; новые подписки сразу получают закрытый канал
+// Вызывается при остановке сервера, чтобы открытые SSE соединения завершились
+func (b *Broker) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.closed = true
+	for sub := range b.subs {
+		b.remove(sub)
+	}
+}
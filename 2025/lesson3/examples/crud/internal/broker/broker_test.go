@@ -0,0 +1,67 @@
+package broker
+
+import (
+	"testing"
+
+	"crud-example/internal/model"
+)
+
+// TestSubscribeReplay - после Last-Event-ID приходят только события пользователя,
+// вытесненный из буфера ID требует перечитать задачи
+func TestSubscribeReplay(t *testing.T) {
+	b := New(3)
+	for id := int64(1); id <= 4; id++ {
+		b.Publish(&model.TodoEvent{ID: id, ActorID: 1 + id%2})
+	}
+	// в буфере события 2, 3, 4; пользователю 1 принадлежат 2 и 4
+
+	tests := []struct {
+		lastEventID int64
+		want        []int64
+		ok          bool
+	}{
+		{lastEventID: 0, ok: true},
+		{lastEventID: 2, want: []int64{4}, ok: true},
+		{lastEventID: 3, want: []int64{4}, ok: true},
+		{lastEventID: 4, ok: true},
+		{lastEventID: 1, ok: false},
+	}
+
+	for _, tt := range tests {
+		sub, replay, ok := b.Subscribe(1, tt.lastEventID)
+		sub.Close()
+
+		var got []int64
+		for _, event := range replay {
+			got = append(got, event.ID)
+		}
+		if ok != tt.ok || len(got) != len(tt.want) || (len(got) > 0 && got[0] != tt.want[0]) {
+			t.Errorf("Subscribe(1, %d) = %v, %v; want %v, %v", tt.lastEventID, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+// TestSlowSubscriber - подписчик, который не забирает события, отключается, а Publish не блокируется
+func TestSlowSubscriber(t *testing.T) {
+	b := New(10)
+	slow, _, _ := b.Subscribe(1, 0)
+	other, _, _ := b.Subscribe(2, 0)
+
+	for id := int64(1); id <= subscriptionBuffer+1; id++ {
+		b.Publish(&model.TodoEvent{ID: id, ActorID: 1})
+	}
+
+	n := 0
+	for range slow.C {
+		n++
+	}
+	if n != subscriptionBuffer {
+		t.Errorf("slow subscriber got %d events before close, want %d", n, subscriptionBuffer)
+	}
+
+	b.Close()
+	if _, open := <-other.C; open {
+		t.Error("Close must close all subscriptions")
+	}
+	other.Close() // повторное закрытие безопасно
+}
@@ -0,0 +1,250 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testSecret = "0123456789abcdef0123456789abcdef"
+
+// envKeys - все переменные окружения, которые читает Load
+var envKeys = []string{
+	"CONFIG_FILE", "HTTP_ADDR", "DATABASE_URL", "JWT_SECRET", "LOG_LEVEL", "LOG_FORMAT",
+	"HTTP_READ_HEADER_TIMEOUT", "HTTP_READ_TIMEOUT", "HTTP_WRITE_TIMEOUT", "HTTP_IDLE_TIMEOUT",
+	"HTTP_SHUTDOWN_TIMEOUT", "HTTP_MAX_BODY_BYTES", "DB_MAX_OPEN_CONNS", "DB_MAX_IDLE_CONNS",
+	"DB_CONN_MAX_LIFETIME", "DB_CONN_MAX_IDLE_TIME", "TODOS_MAX_BATCH_SIZE", "TODOS_TRASH_RETENTION",
+	"TODOS_PURGE_INTERVAL", "TODOS_MAX_IMPORT_SIZE", "STREAM_REPLAY_BUFFER", "STREAM_HEARTBEAT_INTERVAL",
+	"STREAM_PG_NOTIFY",
+}
+
+// clearEnv - убирает переменные окружения Load на время теста, чтобы окружение
+// машины, на которой запущены тесты, не влияло на результат
+func clearEnv(t *testing.T) {
+	t.Helper()
+
+	for _, key := range envKeys {
+		t.Setenv(key, "") // восстановит прежнее значение после теста
+		os.Unsetenv(key)
+	}
+}
+
+func writeConfigFile(t *testing.T, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoad(t *testing.T) {
+	t.Run("defaults and env", func(t *testing.T) {
+		clearEnv(t)
+		t.Setenv("JWT_SECRET", testSecret)
+
+		cfg, err := Load()
+		if err != nil {
+			t.Fatalf("Load: %v", err)
+		}
+
+		want := Default()
+		want.Auth.JWTSecret = testSecret
+		if cfg != want {
+			t.Errorf("Load() = %+v, want defaults with JWT secret", cfg)
+		}
+	})
+
+	t.Run("env overrides file, file overrides defaults", func(t *testing.T) {
+		clearEnv(t)
+		t.Setenv("CONFIG_FILE", writeConfigFile(t, `
+http:
+  addr: ":9000"
+  read_timeout: 20s
+database:
+  max_open_conns: 50
+auth:
+  jwt_secret: "`+testSecret+`"
+log:
+  level: debug
+stream:
+  pg_notify: true
+`))
+		t.Setenv("HTTP_ADDR", ":9090")
+		t.Setenv("DB_MAX_IDLE_CONNS", "10")
+		t.Setenv("STREAM_PG_NOTIFY", "false")
+		t.Setenv("TODOS_TRASH_RETENTION", "72h")
+
+		cfg, err := Load()
+		if err != nil {
+			t.Fatalf("Load: %v", err)
+		}
+
+		checks := []struct {
+			name      string
+			got, want any
+		}{
+			{"http.addr (env)", cfg.HTTP.Addr, ":9090"},
+			{"http.read_timeout (file)", cfg.HTTP.ReadTimeout, 20 * time.Second},
+			{"http.write_timeout (default)", cfg.HTTP.WriteTimeout, Default().HTTP.WriteTimeout},
+			{"database.max_open_conns (file)", cfg.Database.MaxOpenConns, 50},
+			{"database.max_idle_conns (env)", cfg.Database.MaxIdleConns, 10},
+			{"auth.jwt_secret (file)", cfg.Auth.JWTSecret, testSecret},
+			{"log.level (file)", cfg.Log.Level, "debug"},
+			{"stream.pg_notify (env)", cfg.Stream.PGNotify, false},
+			{"todos.trash_retention (env)", cfg.Todos.TrashRetention, 72 * time.Hour},
+		}
+		for _, c := range checks {
+			if c.got != c.want {
+				t.Errorf("%s = %v, want %v", c.name, c.got, c.want)
+			}
+		}
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		tests := []struct {
+			name string
+			env  map[string]string
+			file string // содержимое CONFIG_FILE; "" - файла нет
+			want []string
+		}{
+			{
+				name: "invalid env values are reported together",
+				env:  map[string]string{"JWT_SECRET": testSecret, "DB_MAX_OPEN_CONNS": "many", "HTTP_READ_TIMEOUT": "10", "STREAM_PG_NOTIFY": "yes"},
+				want: []string{`DB_MAX_OPEN_CONNS: invalid integer "many"`, `HTTP_READ_TIMEOUT: invalid duration "10"`, `STREAM_PG_NOTIFY: invalid boolean "yes"`},
+			},
+			{
+				name: "missing file",
+				env:  map[string]string{"JWT_SECRET": testSecret, "CONFIG_FILE": "/nonexistent/config.yaml"},
+				want: []string{"read config file"},
+			},
+			{
+				name: "invalid yaml",
+				env:  map[string]string{"JWT_SECRET": testSecret},
+				file: "http: [addr",
+				want: []string{"parse config file"},
+			},
+			{
+				name: "validation",
+				env:  map[string]string{"DB_MAX_IDLE_CONNS": "100"},
+				want: []string{"auth.jwt_secret is required", "database.max_idle_conns"},
+			},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				clearEnv(t)
+				for key, value := range tt.env {
+					t.Setenv(key, value)
+				}
+				if tt.file != "" {
+					t.Setenv("CONFIG_FILE", writeConfigFile(t, tt.file))
+				}
+
+				_, err := Load()
+				if err == nil {
+					t.Fatal("Load() succeeded, want error")
+				}
+				for _, want := range tt.want {
+					if !strings.Contains(err.Error(), want) {
+						t.Errorf("Load() error %q does not mention %q", err, want)
+					}
+				}
+			})
+		}
+	})
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(c *Config)
+		want   string // "" - конфигурация корректна
+	}{
+		{name: "valid", modify: func(c *Config) {}},
+		{name: "http.addr", modify: func(c *Config) { c.HTTP.Addr = "" }, want: "http.addr is required"},
+		{name: "http timeout", modify: func(c *Config) { c.HTTP.ShutdownTimeout = 0 }, want: "http timeouts must be positive"},
+		{name: "http.max_body_bytes", modify: func(c *Config) { c.HTTP.MaxBodyBytes = 0 }, want: "http.max_body_bytes must be positive"},
+		{name: "database.dsn", modify: func(c *Config) { c.Database.DSN = "" }, want: "database.dsn is required"},
+		{name: "database.max_open_conns", modify: func(c *Config) { c.Database.MaxOpenConns = 0 }, want: "database.max_open_conns must be positive"},
+		{name: "database.max_idle_conns negative", modify: func(c *Config) { c.Database.MaxIdleConns = -1 }, want: "database.max_idle_conns"},
+		{name: "database.max_idle_conns above max_open_conns", modify: func(c *Config) { c.Database.MaxIdleConns = 26 }, want: "database.max_idle_conns"},
+		{name: "database timeouts", modify: func(c *Config) { c.Database.ConnMaxIdleTime = -time.Second }, want: "database connection timeouts"},
+		{name: "jwt_secret missing", modify: func(c *Config) { c.Auth.JWTSecret = "" }, want: "auth.jwt_secret is required"},
+		{name: "jwt_secret placeholder", modify: func(c *Config) { c.Auth.JWTSecret = "super-secret-key-change-in-production" }, want: "placeholder"},
+		{name: "jwt_secret example", modify: func(c *Config) { c.Auth.JWTSecret = "change-me" }, want: "placeholder"},
+		{name: "jwt_secret too short", modify: func(c *Config) { c.Auth.JWTSecret = testSecret[:MinJWTSecretLength-1] }, want: "at least 32 bytes"},
+		{name: "todos.max_batch_size", modify: func(c *Config) { c.Todos.MaxBatchSize = 1001 }, want: "todos.max_batch_size"},
+		{name: "todos.purge_interval", modify: func(c *Config) { c.Todos.PurgeInterval = 0 }, want: "todos.trash_retention and todos.purge_interval"},
+		{name: "todos.max_import_size", modify: func(c *Config) { c.Todos.MaxImportSize = 0 }, want: "todos.max_import_size"},
+		{name: "stream.replay_buffer", modify: func(c *Config) { c.Stream.ReplayBuffer = 0 }, want: "stream.replay_buffer"},
+		{name: "log.level", modify: func(c *Config) { c.Log.Level = "verbose" }, want: "log.level"},
+		{name: "log.format", modify: func(c *Config) { c.Log.Format = "xml" }, want: "log.format"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := Default()
+			cfg.Auth.JWTSecret = testSecret
+			tt.modify(&cfg)
+
+			err := cfg.Validate()
+			if tt.want == "" {
+				if err != nil {
+					t.Errorf("Validate() = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Validate() = %v, want error mentioning %q", err, tt.want)
+			}
+		})
+	}
+
+	t.Run("all errors at once", func(t *testing.T) {
+		cfg := Default()
+		cfg.HTTP.Addr = ""
+		cfg.Log.Format = "xml"
+
+		err := cfg.Validate()
+		if err == nil || strings.Count(err.Error(), "\n") != 2 {
+			t.Errorf("Validate() = %q, want 3 errors (addr, jwt_secret, log.format)", err)
+		}
+	})
+}
+
+func TestRedacted(t *testing.T) {
+	cfg := Default()
+	cfg.Database.DSN = "postgres://app:s3cr3t-pass@db:5432/todos?sslmode=disable"
+	cfg.Auth.JWTSecret = testSecret
+
+	redacted := cfg.Redacted()
+	if redacted.Database.DSN != "postgres://app:xxxxx@db:5432/todos?sslmode=disable" {
+		t.Errorf("DSN = %q, want password hidden", redacted.Database.DSN)
+	}
+	if redacted.Auth.JWTSecret != "xxxxx" {
+		t.Errorf("JWTSecret = %q, want hidden", redacted.Auth.JWTSecret)
+	}
+	if cfg.Database.DSN == redacted.Database.DSN || cfg.Auth.JWTSecret != testSecret {
+		t.Error("Redacted changed the original config")
+	}
+
+	// DSN в формате key=value нельзя разобрать как URL: скрывается целиком
+	cfg.Database.DSN = "host=db user=app password=s3cr3t-pass dbname=todos"
+	if dsn := cfg.Redacted().Database.DSN; dsn != "xxxxx" {
+		t.Errorf("key=value DSN = %q, want xxxxx", dsn)
+	}
+
+	s := cfg.String()
+	for _, secret := range []string{"s3cr3t-pass", testSecret} {
+		if strings.Contains(s, secret) {
+			t.Errorf("String() leaks %q:\n%s", secret, s)
+		}
+	}
+	if !strings.Contains(s, "jwt_secret: xxxxx") {
+		t.Errorf("String() has no redacted jwt_secret:\n%s", s)
+	}
+}
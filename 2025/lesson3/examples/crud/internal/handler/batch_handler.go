@@ -0,0 +1,119 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"crud-example/internal/auth"
+	"crud-example/internal/model"
+	"crud-example/internal/response"
+	"crud-example/internal/service"
+	"crud-example/internal/validate"
+)
+
+// CreateTodosBatchRequest - DTO для пакетного создания задач
+type CreateTodosBatchRequest struct {
+	Items []CreateTodoRequest `json:"items" validate:"required"`
+}
+
+// CreateTodosBatchResponse - созданные задачи в том же порядке, что и в запросе
+type CreateTodosBatchResponse struct {
+	Items []TodoResponse `json:"items"`
+}
+
+// TodoIDsRequest - DTO со списком ID для пакетных операций
+type TodoIDsRequest struct {
+	IDs []int64 `json:"ids" validate:"required"`
+}
+
+// CreateTodosBatch - POST /todos/batch - создать несколько задач одним запросом
+// Либо создаются все задачи, либо ни одной; ошибки валидации возвращаются по индексам
+func (h *TodoHandler) CreateTodosBatch(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		response.Error(w, http.StatusUnauthorized, response.CodeUnauthorized, "unauthorized")
+		return
+	}
+
+	var req CreateTodosBatchRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+
+	// Каждый элемент проверяется отдельно, ошибки возвращаются с индексом элемента
+	var itemErrs []model.ItemError
+	items := make([]service.NewTodo, 0, len(req.Items))
+	for i, item := range req.Items {
+		var fieldsErr *model.ValidationErrors
+		if err := validate.Struct(item); errors.As(err, &fieldsErr) {
+			for _, f := range fieldsErr.Fields {
+				itemErrs = append(itemErrs, model.ItemError{Index: i, Field: f.Field, Message: f.Message})
+			}
+			continue
+		}
+		items = append(items, item.newTodoInput())
+	}
+
+	if len(itemErrs) > 0 {
+		writeServiceError(w, r, &model.BatchValidationError{Items: itemErrs})
+		return
+	}
+
+	todos, err := h.service.CreateTodos(r.Context(), userID, items)
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	resp := CreateTodosBatchResponse{Items: make([]TodoResponse, 0, len(todos))}
+	for _, todo := range todos {
+		resp.Items = append(resp.Items, newTodoResponse(todo))
+	}
+
+	response.JSON(w, http.StatusCreated, resp)
+}
+
+// CompleteTodosBatch - POST /todos/batch/complete - отметить выполненными несколько задач
+func (h *TodoHandler) CompleteTodosBatch(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		response.Error(w, http.StatusUnauthorized, response.CodeUnauthorized, "unauthorized")
+		return
+	}
+
+	var req TodoIDsRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+
+	n, err := h.service.CompleteTodos(r.Context(), userID, req.IDs)
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	// Уже выполненные задачи не считаются: completed может быть меньше len(ids)
+	response.JSON(w, http.StatusOK, map[string]int64{"completed": n})
+}
+
+// DeleteTodosBatch - DELETE /todos/batch - удалить несколько задач
+func (h *TodoHandler) DeleteTodosBatch(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		response.Error(w, http.StatusUnauthorized, response.CodeUnauthorized, "unauthorized")
+		return
+	}
+
+	var req TodoIDsRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+
+	n, err := h.service.DeleteTodos(r.Context(), userID, req.IDs)
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	response.JSON(w, http.StatusOK, map[string]int64{"deleted": n})
+}
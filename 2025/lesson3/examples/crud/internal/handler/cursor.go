@@ -0,0 +1,31 @@
+package handler
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+
+	"crud-example/internal/model"
+)
+
+// encodeCursor - превращает позицию в непрозрачную для клиента строку
+// Клиент не должен разбирать курсор, он просто передает его в следующий запрос
+func encodeCursor(cursor *model.TodoCursor) string {
+	data, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeCursor - разбирает курсор, полученный от клиента
+func decodeCursor(s string) (*model.TodoCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, errors.New("invalid cursor")
+	}
+
+	cursor := &model.TodoCursor{}
+	if err := json.Unmarshal(data, cursor); err != nil || cursor.ID <= 0 || cursor.CreatedAt.IsZero() {
+		return nil, errors.New("invalid cursor")
+	}
+
+	return cursor, nil
+}
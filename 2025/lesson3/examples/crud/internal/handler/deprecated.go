@@ -0,0 +1,21 @@
+package handler
+
+import (
+	"net/http"
+	"net/url"
+)
+
+// DeprecatedQueryID - адаптер для старых маршрутов вида /todos/get?id=1
+// Переносит id из query-строки в path value, чтобы handler читал его через r.PathValue("id"),
+// и сообщает клиенту о новом адресе через заголовки Deprecation и Link
+func DeprecatedQueryID(successor string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := r.URL.Query().Get("id")
+		r.SetPathValue("id", id)
+
+		w.Header().Set("Deprecation", "true")
+		w.Header().Set("Link", "</todos/"+url.PathEscape(id)+successor+`>; rel="successor-version"`)
+
+		next(w, r)
+	}
+}
@@ -0,0 +1,62 @@
+package handler
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"gopkg.in/yaml.v3"
+)
+
+// openAPIYAML - спецификация API; пишем в YAML (так короче), отдаем в JSON
+//
+//go:embed docs/openapi.yaml
+var openAPIYAML []byte
+
+// swaggerUIHTML - страница Swagger UI, которая загружает /openapi.json
+//
+//go:embed docs/swagger.html
+var swaggerUIHTML []byte
+
+// DocsHandler - документация API: OpenAPI 3 спецификация и Swagger UI
+type DocsHandler struct {
+	spec []byte // openapi.yaml, сконвертированный в JSON
+}
+
+// NewDocsHandler - разбирает встроенную спецификацию
+// Ошибка означает, что openapi.yaml сломан, и сервер не должен стартовать
+func NewDocsHandler() (*DocsHandler, error) {
+	spec, err := OpenAPISpec()
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := json.Marshal(spec)
+	if err != nil {
+		return nil, fmt.Errorf("encode openapi spec: %w", err)
+	}
+
+	return &DocsHandler{spec: data}, nil
+}
+
+// OpenAPISpec - встроенная спецификация в виде map (для проверок в тестах)
+func OpenAPISpec() (map[string]any, error) {
+	var spec map[string]any
+	if err := yaml.Unmarshal(openAPIYAML, &spec); err != nil {
+		return nil, fmt.Errorf("parse openapi.yaml: %w", err)
+	}
+	return spec, nil
+}
+
+// Spec - GET /openapi.json - спецификация OpenAPI 3
+func (h *DocsHandler) Spec(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(h.spec)
+}
+
+// SwaggerUI - GET /docs - интерактивная документация
+func (h *DocsHandler) SwaggerUI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write(swaggerUIHTML)
+}
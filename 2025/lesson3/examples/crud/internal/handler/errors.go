@@ -0,0 +1,61 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"crud-example/internal/logger"
+	"crud-example/internal/model"
+	"crud-example/internal/response"
+)
+
+// writeServiceError - переводит доменную ошибку в HTTP ответ с единым форматом
+// Детали внутренних ошибок (500) пишем в лог запроса, а клиент получает только
+// общий текст; найти запись в логе можно по заголовку X-Request-ID ответа
+func writeServiceError(w http.ResponseWriter, r *http.Request, err error) {
+	var (
+		validationErr *model.ValidationError
+		fieldsErr     *model.ValidationErrors
+		batchErr      *model.BatchValidationError
+		importErr     *model.ImportValidationError
+		missingErr    *model.MissingTodosError
+		blockedErr    *model.BlockedTodoError
+	)
+
+	switch {
+	case errors.As(err, &batchErr):
+		response.ErrorWithDetails(w, http.StatusUnprocessableEntity, response.CodeValidation,
+			batchErr.Error(), batchErr.Items)
+	case errors.As(err, &importErr):
+		response.ErrorWithDetails(w, http.StatusUnprocessableEntity, response.CodeValidation,
+			importErr.Error(), importErr.Lines)
+	case errors.As(err, &missingErr):
+		response.ErrorWithDetails(w, http.StatusNotFound, response.CodeNotFound,
+			"todos not found", map[string][]int64{"ids": missingErr.IDs})
+	case errors.As(err, &blockedErr):
+		response.ErrorWithDetails(w, http.StatusConflict, response.CodeConflict,
+			"todo is blocked by open todos", map[string]any{"todo_id": blockedErr.ID, "blocked_by": blockedErr.BlockedBy})
+	case errors.As(err, &fieldsErr):
+		response.ErrorWithDetails(w, http.StatusUnprocessableEntity, response.CodeValidation,
+			fieldsErr.Error(), fieldsErr.Fields)
+	case errors.As(err, &validationErr):
+		// Тот же формат details, что и для нескольких полей: клиенту не нужно различать случаи
+		response.ErrorWithDetails(w, http.StatusUnprocessableEntity, response.CodeValidation,
+			validationErr.Error(), []model.FieldError{{Field: validationErr.Field, Message: validationErr.Message}})
+	case errors.Is(err, model.ErrValidation):
+		response.Error(w, http.StatusUnprocessableEntity, response.CodeValidation, "validation failed")
+	case errors.Is(err, model.ErrTodoNotFound):
+		response.Error(w, http.StatusNotFound, response.CodeNotFound, "todo not found")
+	case errors.Is(err, model.ErrNotFound):
+		response.Error(w, http.StatusNotFound, response.CodeNotFound, "not found")
+	case errors.Is(err, model.ErrVersionConflict):
+		response.Error(w, http.StatusPreconditionFailed, response.CodePreconditionFailed, "todo was modified, reload it and retry")
+	case errors.Is(err, model.ErrConflict):
+		response.Error(w, http.StatusConflict, response.CodeConflict, "conflict")
+	case errors.Is(err, model.ErrForbidden):
+		response.Error(w, http.StatusForbidden, response.CodeForbidden, "forbidden")
+	default:
+		logger.FromContext(r.Context()).Error("internal error", "error", err)
+		response.Error(w, http.StatusInternalServerError, response.CodeInternal, "internal server error")
+	}
+}
@@ -0,0 +1,37 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"crud-example/internal/service"
+)
+
+// formatETag - ETag задачи строится из ее версии: версия 3 → "3"
+func formatETag(version int64) string {
+	return strconv.Quote(strconv.FormatInt(version, 10))
+}
+
+// parseIfMatch - читает заголовок If-Match и возвращает ожидаемую версию задачи
+// Без заголовка или с "If-Match: *" версия не проверяется (service.AnyVersion).
+// ok == false, если заголовок не удалось разобрать: такой ETag заведомо не совпадет
+func parseIfMatch(r *http.Request) (version int64, ok bool) {
+	header := strings.TrimSpace(r.Header.Get("If-Match"))
+	if header == "" || header == "*" {
+		return service.AnyVersion, true
+	}
+
+	// Слабые ETag (W/"3") не подходят для If-Match (RFC 9110, strong comparison)
+	unquoted, err := strconv.Unquote(header)
+	if err != nil {
+		return 0, false
+	}
+
+	version, err = strconv.ParseInt(unquoted, 10, 64)
+	if err != nil || version <= 0 {
+		return 0, false
+	}
+
+	return version, true
+}
@@ -0,0 +1,90 @@
+package handler
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"crud-example/internal/response"
+)
+
+// pinger - то, что нужно HealthHandler от базы (реализует *sqlx.DB)
+type pinger interface {
+	PingContext(ctx context.Context) error
+	Stats() sql.DBStats
+}
+
+// HealthHandler - liveness и readiness пробы
+type HealthHandler struct {
+	db           pinger
+	shuttingDown atomic.Bool
+}
+
+// NewHealthHandler - создает handler проб
+func NewHealthHandler(db pinger) *HealthHandler {
+	return &HealthHandler{db: db}
+}
+
+// PoolStats - состояние пула соединений (из sql.DBStats)
+type PoolStats struct {
+	MaxOpen      int    `json:"max_open"`
+	Open         int    `json:"open"`
+	InUse        int    `json:"in_use"`
+	Idle         int    `json:"idle"`
+	WaitCount    int64  `json:"wait_count"`
+	WaitDuration string `json:"wait_duration"`
+}
+
+// ReadyResponse - ответ /readyz
+type ReadyResponse struct {
+	Status string    `json:"status"`
+	Error  string    `json:"error,omitempty"`
+	Pool   PoolStats `json:"pool"`
+}
+
+// SetShuttingDown - с этого момента /readyz отвечает 503,
+// чтобы балансировщик перестал присылать новые запросы
+func (h *HealthHandler) SetShuttingDown() {
+	h.shuttingDown.Store(true)
+}
+
+// Liveness - GET /healthz - процесс жив (база не проверяется)
+func (h *HealthHandler) Liveness(w http.ResponseWriter, r *http.Request) {
+	response.JSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+// Readiness - GET /readyz - сервер готов принимать запросы (база отвечает)
+func (h *HealthHandler) Readiness(w http.ResponseWriter, r *http.Request) {
+	stats := h.db.Stats()
+	resp := ReadyResponse{
+		Status: "ok",
+		Pool: PoolStats{
+			MaxOpen:      stats.MaxOpenConnections,
+			Open:         stats.OpenConnections,
+			InUse:        stats.InUse,
+			Idle:         stats.Idle,
+			WaitCount:    stats.WaitCount,
+			WaitDuration: stats.WaitDuration.String(),
+		},
+	}
+
+	if h.shuttingDown.Load() {
+		resp.Status = "shutting_down"
+		response.JSON(w, http.StatusServiceUnavailable, resp)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 2*time.Second)
+	defer cancel()
+
+	if err := h.db.PingContext(ctx); err != nil {
+		resp.Status = "unavailable"
+		resp.Error = "database ping failed"
+		response.JSON(w, http.StatusServiceUnavailable, resp)
+		return
+	}
+
+	response.JSON(w, http.StatusOK, resp)
+}
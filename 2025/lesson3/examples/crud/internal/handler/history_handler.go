@@ -0,0 +1,110 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"crud-example/internal/auth"
+	"crud-example/internal/model"
+	"crud-example/internal/response"
+)
+
+// TodoEventResponse - DTO записи истории изменений задачи
+type TodoEventResponse struct {
+	ID        int64           `json:"id"`
+	TodoID    int64           `json:"todo_id"`
+	Type      string          `json:"type"`
+	Version   int64           `json:"version"`
+	ActorID   int64           `json:"actor_id"`
+	Before    json.RawMessage `json:"before"` // только изменившиеся поля; null для created
+	After     json.RawMessage `json:"after"`
+	CreatedAt string          `json:"created_at"`
+}
+
+// TodoHistoryResponse - история задачи в хронологическом порядке
+type TodoHistoryResponse struct {
+	Items []TodoEventResponse `json:"items"`
+}
+
+// RevertTodoRequest - DTO для отката задачи
+type RevertTodoRequest struct {
+	Version int64 `json:"version" validate:"required,min=1"` // версия из истории, к которой нужно вернуться
+}
+
+// GetTodoHistory - GET /todos/{id}/history - история изменений задачи
+func (h *TodoHandler) GetTodoHistory(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		response.Error(w, http.StatusUnauthorized, response.CodeUnauthorized, "unauthorized")
+		return
+	}
+
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, response.CodeBadRequest, "invalid todo ID")
+		return
+	}
+
+	events, err := h.service.GetTodoHistory(r.Context(), userID, id)
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	resp := TodoHistoryResponse{Items: make([]TodoEventResponse, 0, len(events))}
+	for _, event := range events {
+		resp.Items = append(resp.Items, newTodoEventResponse(event))
+	}
+
+	response.JSON(w, http.StatusOK, resp)
+}
+
+// RevertTodo - POST /todos/{id}/revert - вернуть задачу к версии из истории
+// Откат создает новую версию задачи; If-Match сверяется с текущей версией
+func (h *TodoHandler) RevertTodo(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		response.Error(w, http.StatusUnauthorized, response.CodeUnauthorized, "unauthorized")
+		return
+	}
+
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, response.CodeBadRequest, "invalid todo ID")
+		return
+	}
+
+	ifVersion, ok := parseIfMatch(r)
+	if !ok {
+		response.Error(w, http.StatusPreconditionFailed, response.CodePreconditionFailed, "If-Match does not match current ETag")
+		return
+	}
+
+	var req RevertTodoRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+
+	todo, err := h.service.RevertTodo(r.Context(), userID, id, ifVersion, req.Version)
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	w.Header().Set("ETag", formatETag(todo.Version))
+	response.JSON(w, http.StatusOK, newTodoResponse(todo))
+}
+
+func newTodoEventResponse(event *model.TodoEvent) TodoEventResponse {
+	return TodoEventResponse{
+		ID:        event.ID,
+		TodoID:    event.TodoID,
+		Type:      string(event.Type),
+		Version:   event.Version,
+		ActorID:   event.ActorID,
+		Before:    event.Before,
+		After:     event.After,
+		CreatedAt: event.CreatedAt.Format("2006-01-02 15:04:05"),
+	}
+}
@@ -0,0 +1,50 @@
+package handler
+
+import (
+	"net/http"
+
+	"crud-example/internal/response"
+)
+
+// MethodNotAllowed - оборачивает mux, чтобы его автоматический 405 отдавался в едином формате ошибки
+// Заголовок Allow ставит сам mux, здесь заменяется только текстовое тело
+func MethodNotAllowed(mux *http.ServeMux) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Маршрут найден - ответ пишет handler маршрута, перехватывать нечего
+		if _, pattern := mux.Handler(r); pattern != "" {
+			mux.ServeHTTP(w, r)
+			return
+		}
+
+		mux.ServeHTTP(&methodNotAllowedWriter{ResponseWriter: w}, r)
+	})
+}
+
+// methodNotAllowedWriter - заменяет ответ 405 от mux на JSON, остальные ответы пропускает как есть
+type methodNotAllowedWriter struct {
+	http.ResponseWriter
+	replaced bool
+}
+
+func (w *methodNotAllowedWriter) WriteHeader(status int) {
+	if status != http.StatusMethodNotAllowed {
+		w.ResponseWriter.WriteHeader(status)
+		return
+	}
+
+	w.replaced = true
+	w.Header().Del("X-Content-Type-Options")
+	response.Error(w.ResponseWriter, status, response.CodeMethodNotAllowed, "method not allowed")
+}
+
+func (w *methodNotAllowedWriter) Write(b []byte) (int, error) {
+	// Текст "Method Not Allowed" от http.Error отбрасываем: тело уже записано
+	if w.replaced {
+		return len(b), nil
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *methodNotAllowedWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
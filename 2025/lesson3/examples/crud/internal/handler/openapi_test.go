@@ -0,0 +1,124 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// TestOpenAPICoversRoutes - каждый маршрут из Routes описан в docs/openapi.yaml, и наоборот
+// Добавили маршрут и забыли спецификацию - тест упадет
+func TestOpenAPICoversRoutes(t *testing.T) {
+	spec, err := OpenAPISpec()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	paths, ok := spec["paths"].(map[string]any)
+	if !ok {
+		t.Fatal("spec has no paths")
+	}
+
+	documented := make(map[string]bool)
+	for path, item := range paths {
+		operations, ok := item.(map[string]any)
+		if !ok {
+			t.Fatalf("path %s: not an object", path)
+		}
+		for method := range operations {
+			switch method {
+			case "get", "put", "post", "delete", "patch", "head", "options":
+				documented[strings.ToUpper(method)+" "+path] = true
+			}
+		}
+	}
+
+	// Обработчики не вызываются, поэтому зависимости не нужны
+	registered := make(map[string]bool)
+	for _, route := range Routes(nil, nil, nil, nil, nil) {
+		registered[route.Pattern] = true
+		if !documented[route.Pattern] {
+			t.Errorf("route %q is missing from openapi.yaml", route.Pattern)
+		}
+	}
+
+	for op := range documented {
+		if !registered[op] {
+			t.Errorf("openapi.yaml describes %q, but no such route is registered", op)
+		}
+	}
+}
+
+// TestOpenAPIRefsResolve - все $ref указывают на существующие компоненты
+func TestOpenAPIRefsResolve(t *testing.T) {
+	spec, err := OpenAPISpec()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var walk func(v any)
+	walk = func(v any) {
+		switch v := v.(type) {
+		case map[string]any:
+			if ref, ok := v["$ref"].(string); ok {
+				if !resolveRef(spec, ref) {
+					t.Errorf("unresolved $ref %q", ref)
+				}
+			}
+			for _, child := range v {
+				walk(child)
+			}
+		case []any:
+			for _, child := range v {
+				walk(child)
+			}
+		}
+	}
+	walk(spec)
+}
+
+func TestDocsHandler(t *testing.T) {
+	docs, err := NewDocsHandler()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w := httptest.NewRecorder()
+	docs.Spec(w, httptest.NewRequest(http.MethodGet, "/openapi.json", nil))
+
+	var spec map[string]any
+	if err := json.Unmarshal(w.Body.Bytes(), &spec); err != nil {
+		t.Fatalf("/openapi.json is not JSON: %v", err)
+	}
+	if spec["openapi"] != "3.0.3" {
+		t.Errorf("openapi = %v, want 3.0.3", spec["openapi"])
+	}
+
+	w = httptest.NewRecorder()
+	docs.SwaggerUI(w, httptest.NewRequest(http.MethodGet, "/docs", nil))
+	if !strings.Contains(w.Body.String(), `url: "/openapi.json"`) {
+		t.Error("/docs does not load /openapi.json")
+	}
+}
+
+// resolveRef - "#/components/schemas/Todo" → spec["components"]["schemas"]["Todo"] существует
+func resolveRef(spec map[string]any, ref string) bool {
+	path, ok := strings.CutPrefix(ref, "#/")
+	if !ok {
+		return false
+	}
+
+	var node any = spec
+	for _, key := range strings.Split(path, "/") {
+		m, ok := node.(map[string]any)
+		if !ok {
+			return false
+		}
+		if node, ok = m[key]; !ok {
+			return false
+		}
+	}
+	return true
+}
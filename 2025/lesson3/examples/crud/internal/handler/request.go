@@ -0,0 +1,124 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"time"
+
+	"crud-example/internal/model"
+	"crud-example/internal/response"
+	"crud-example/internal/validate"
+)
+
+// errTrailingData - после JSON значения в теле есть что-то еще
+var errTrailingData = errors.New("unexpected data after JSON value")
+
+// LimitBody - middleware, ограничивающее размер тела запроса maxBytes байтами
+// Чтение сверх лимита возвращает *http.MaxBytesError, decodeJSON превращает ее в 413
+func LimitBody(maxBytes int64) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			r.Body = http.MaxBytesReader(w, r.Body, maxBytes)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// decodeRequest - строго разбирает JSON тело в DTO и проверяет его теги `validate`
+// При ошибке сам отправляет ответ (см. writeRequestError) и возвращает false
+func decodeRequest(w http.ResponseWriter, r *http.Request, dst any) bool {
+	if err := decodeJSON(r.Body, dst, true); err != nil {
+		writeRequestError(w, r, err)
+		return false
+	}
+
+	if err := validate.Struct(dst); err != nil {
+		writeRequestError(w, r, err)
+		return false
+	}
+
+	return true
+}
+
+// decodeJSON - читает из body ровно одно JSON значение
+// strict - поля, которых нет в DTO, считаются ошибкой, а не молча игнорируются
+func decodeJSON(body io.Reader, dst any, strict bool) error {
+	dec := json.NewDecoder(body)
+	if strict {
+		dec.DisallowUnknownFields()
+	}
+
+	if err := dec.Decode(dst); err != nil {
+		return err
+	}
+
+	// dec.More() не видит лишние "}" и "]": для него это конец объекта, а не новое значение
+	if err := dec.Decode(&struct{}{}); err != io.EOF {
+		return errTrailingData
+	}
+
+	return nil
+}
+
+// writeRequestError - ответ на ошибку разбора или валидации тела запроса:
+// 413 - тело больше лимита, 422 - неизвестное поле, неверный тип или ошибки валидации,
+// 400 - тело не является корректным JSON
+func writeRequestError(w http.ResponseWriter, r *http.Request, err error) {
+	var (
+		maxBytesErr *http.MaxBytesError
+		typeErr     *json.UnmarshalTypeError
+		timeErr     *time.ParseError
+	)
+	unknownName, unknownField := validate.UnknownJSONField(err)
+
+	switch {
+	case errors.Is(err, model.ErrValidation):
+		writeServiceError(w, r, err)
+	case errors.As(err, &maxBytesErr):
+		response.Error(w, http.StatusRequestEntityTooLarge, response.CodePayloadTooLarge,
+			fmt.Sprintf("request body too large (max %d bytes)", maxBytesErr.Limit))
+	case unknownField:
+		writeServiceError(w, r, model.NewValidationError(unknownName, "unknown field"))
+	case errors.As(err, &typeErr):
+		// Пустой Field - неверный тип у всего тела (например, массив вместо объекта)
+		field := typeErr.Field
+		if field == "" {
+			field = "body"
+		}
+		writeServiceError(w, r, model.NewValidationError(field, "must be "+jsonTypeName(typeErr.Type)))
+	case errors.As(err, &timeErr):
+		// time.Time.UnmarshalJSON не сообщает имя поля
+		writeServiceError(w, r, model.NewValidationError("body", "timestamps must be in RFC3339 format"))
+	default:
+		response.Error(w, http.StatusBadRequest, response.CodeBadRequest, "invalid JSON")
+	}
+}
+
+// jsonTypeName - как тип Go выглядит в JSON, для сообщений об ошибках
+func jsonTypeName(t reflect.Type) string {
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return "a string"
+	case reflect.Bool:
+		return "a boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return "a number"
+	case reflect.Slice, reflect.Array:
+		return "an array"
+	}
+
+	if t == reflect.TypeOf(time.Time{}) {
+		return "an RFC3339 timestamp"
+	}
+	return "an object"
+}
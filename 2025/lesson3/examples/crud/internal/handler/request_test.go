@@ -0,0 +1,47 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestDecodeJSONTrailingData(t *testing.T) {
+	tests := []struct {
+		body    string
+		wantErr error // nil - тело корректно
+	}{
+		{body: `{"title":"x"}`},
+		{body: "{\"title\":\"x\"}\n\t "},
+		{body: `{"title":"x"}}`, wantErr: errTrailingData},
+		{body: `{"title":"x"}]`, wantErr: errTrailingData},
+		{body: `{"title":"x"}{"title":"y"}`, wantErr: errTrailingData},
+		{body: `{"title":"x"} 1`, wantErr: errTrailingData},
+		{body: `{"title":"x"} garbage`, wantErr: errTrailingData},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.body, func(t *testing.T) {
+			var dst struct {
+				Title string `json:"title"`
+			}
+			err := decodeJSON(strings.NewReader(tt.body), &dst, true)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("decodeJSON(%q) = %v, want %v", tt.body, err, tt.wantErr)
+			}
+			if err == nil && dst.Title != "x" {
+				t.Errorf("title = %q, want x", dst.Title)
+			}
+		})
+	}
+
+	// Для клиента это некорректный JSON
+	var dst struct{}
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/todos", strings.NewReader(`{}}`))
+	if decodeRequest(rec, req, &dst) || rec.Code != http.StatusBadRequest {
+		t.Errorf("decodeRequest(`{}}`): status %d, want 400", rec.Code)
+	}
+}
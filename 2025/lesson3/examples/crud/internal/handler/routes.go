@@ -0,0 +1,70 @@
+package handler
+
+import (
+	"net/http"
+
+	"crud-example/internal/metrics"
+)
+
+// Route - маршрут API: шаблон Go 1.22 ServeMux ("МЕТОД /путь/{wildcard}") и обработчик
+type Route struct {
+	Pattern string
+	Handler http.HandlerFunc
+	Public  bool // без JWT: пробы и документация
+}
+
+// Routes - все маршруты сервера
+// Список один и для main.go, и для теста, который сверяет маршруты с openapi.yaml
+func Routes(todos *TodoHandler, stream *StreamHandler, health *HealthHandler, docs *DocsHandler, prom *metrics.Metrics) []Route {
+	return []Route{
+		// Пробы для оркестратора (Kubernetes, docker-compose healthcheck)
+		{Pattern: "GET /healthz", Handler: health.Liveness, Public: true},
+		{Pattern: "GET /readyz", Handler: health.Readiness, Public: true},
+
+		// Документация API: OpenAPI 3 и Swagger UI
+		{Pattern: "GET /openapi.json", Handler: docs.Spec, Public: true},
+		{Pattern: "GET /docs", Handler: docs.SwaggerUI, Public: true},
+
+		// Метрики для Prometheus: HTTP, репозиторий, пул соединений
+		{Pattern: "GET /metrics", Handler: prom.ServeHTTP, Public: true},
+
+		{Pattern: "POST /todos", Handler: todos.CreateTodo},
+		{Pattern: "GET /todos", Handler: todos.GetTodos},
+		{Pattern: "GET /todos/{id}", Handler: todos.GetTodo},
+		{Pattern: "PUT /todos/{id}", Handler: todos.UpdateTodo},
+		{Pattern: "PATCH /todos/{id}", Handler: todos.PatchTodo},
+		{Pattern: "DELETE /todos/{id}", Handler: todos.DeleteTodo},
+		{Pattern: "POST /todos/{id}/complete", Handler: todos.CompleteTodo},
+
+		// Подзадачи: дерево задачи с процентом выполнения
+		{Pattern: "GET /todos/{id}/tree", Handler: todos.GetTodoTree},
+
+		// История изменений: каждое изменение через сервис пишется в todo_events
+		{Pattern: "GET /todos/{id}/history", Handler: todos.GetTodoHistory},
+		{Pattern: "POST /todos/{id}/revert", Handler: todos.RevertTodo},
+
+		// Корзина: DELETE только помечает задачу удаленной
+		{Pattern: "GET /todos/trash", Handler: todos.GetTrash},
+		{Pattern: "POST /todos/{id}/restore", Handler: todos.RestoreTodo},
+
+		// Изменения задач в реальном времени (Server-Sent Events)
+		{Pattern: "GET /todos/stream", Handler: stream.Stream},
+
+		// Перенос задач файлами: CSV, JSON Lines, iCalendar
+		{Pattern: "GET /todos/export", Handler: todos.ExportTodos},
+		{Pattern: "POST /todos/import", Handler: todos.ImportTodos},
+
+		{Pattern: "GET /todos/overdue", Handler: todos.GetOverdue},
+		{Pattern: "GET /todos/search", Handler: todos.SearchTodos},
+
+		// Пакетные операции: /todos/batch точнее /todos/{id}, поэтому mux выберет их
+		{Pattern: "POST /todos/batch", Handler: todos.CreateTodosBatch},
+		{Pattern: "POST /todos/batch/complete", Handler: todos.CompleteTodosBatch},
+		{Pattern: "DELETE /todos/batch", Handler: todos.DeleteTodosBatch},
+
+		// Устаревшие маршруты с ?id= оставлены для обратной совместимости
+		{Pattern: "GET /todos/get", Handler: DeprecatedQueryID("", todos.GetTodo)},
+		{Pattern: "POST /todos/complete", Handler: DeprecatedQueryID("/complete", todos.CompleteTodo)},
+		{Pattern: "DELETE /todos/delete", Handler: DeprecatedQueryID("", todos.DeleteTodo)},
+	}
+}
@@ -0,0 +1,75 @@
+package handler
+
+import (
+	"html"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"crud-example/internal/auth"
+	"crud-example/internal/model"
+	"crud-example/internal/response"
+)
+
+// TodoSearchHitResponse - DTO найденной задачи: поля задачи плюс релевантность и сниппеты
+// Сниппеты - это HTML: текст экранирован, совпадения обернуты в <mark>...</mark>
+type TodoSearchHitResponse struct {
+	TodoResponse
+	Rank               float64 `json:"rank"`
+	TitleSnippet       string  `json:"title_snippet"`
+	DescriptionSnippet string  `json:"description_snippet"`
+}
+
+// TodoSearchResponse - DTO результатов поиска
+type TodoSearchResponse struct {
+	Items []TodoSearchHitResponse `json:"items"`
+}
+
+// snippetReplacer - маркеры подсветки из репозитория → HTML теги
+var snippetReplacer = strings.NewReplacer(
+	model.HighlightStart, "<mark>",
+	model.HighlightStop, "</mark>",
+)
+
+// newSnippet - экранирует текст задачи (он пришел от пользователя) и расставляет <mark>
+func newSnippet(s string) string {
+	return snippetReplacer.Replace(html.EscapeString(s))
+}
+
+// SearchTodos - GET /todos/search?q=...&limit=... - полнотекстовый поиск по задачам
+func (h *TodoHandler) SearchTodos(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		response.Error(w, http.StatusUnauthorized, response.CodeUnauthorized, "unauthorized")
+		return
+	}
+
+	query := r.URL.Query()
+
+	var limit int
+	if v := query.Get("limit"); v != "" {
+		var err error
+		if limit, err = strconv.Atoi(v); err != nil {
+			response.Error(w, http.StatusBadRequest, response.CodeBadRequest, "invalid limit: "+strconv.Quote(v))
+			return
+		}
+	}
+
+	hits, err := h.service.SearchTodos(r.Context(), userID, query.Get("q"), limit)
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	resp := TodoSearchResponse{Items: make([]TodoSearchHitResponse, 0, len(hits))}
+	for _, hit := range hits {
+		resp.Items = append(resp.Items, TodoSearchHitResponse{
+			TodoResponse:       newTodoResponse(hit.Todo),
+			Rank:               hit.Rank,
+			TitleSnippet:       newSnippet(hit.TitleSnippet),
+			DescriptionSnippet: newSnippet(hit.DescriptionSnippet),
+		})
+	}
+
+	response.JSON(w, http.StatusOK, resp)
+}
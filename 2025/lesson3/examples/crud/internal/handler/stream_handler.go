@@ -0,0 +1,136 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"crud-example/internal/auth"
+	"crud-example/internal/broker"
+	"crud-example/internal/logger"
+	"crud-example/internal/model"
+	"crud-example/internal/response"
+)
+
+// DefaultHeartbeatInterval - как часто в поток пишется комментарий, если событий нет
+// Без него прокси и балансировщики закрывают "молчащие" соединения
+const DefaultHeartbeatInterval = 15 * time.Second
+
+// streamRetry - через сколько миллисекунд EventSource переподключается после обрыва
+const streamRetry = 3000
+
+// StreamHandler - поток изменений задач (Server-Sent Events)
+type StreamHandler struct {
+	broker    *broker.Broker
+	heartbeat time.Duration
+}
+
+// NewStreamHandler - создает обработчик GET /todos/stream
+func NewStreamHandler(b *broker.Broker, heartbeat time.Duration) *StreamHandler {
+	if heartbeat <= 0 {
+		heartbeat = DefaultHeartbeatInterval
+	}
+	return &StreamHandler{broker: b, heartbeat: heartbeat}
+}
+
+// Stream - GET /todos/stream - изменения задач пользователя в формате text/event-stream
+// Каждое событие - запись истории (как в GET /todos/{id}/history), id события - ее ID.
+// После обрыва EventSource сам присылает Last-Event-ID и получает пропущенные события;
+// если их уже нет в буфере, приходит событие reset - задачи нужно перечитать целиком
+func (h *StreamHandler) Stream(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		response.Error(w, http.StatusUnauthorized, response.CodeUnauthorized, "unauthorized")
+		return
+	}
+
+	// Браузерный EventSource не умеет задавать заголовки при первом подключении,
+	// поэтому позицию можно передать и параметром last_event_id
+	lastID := r.Header.Get("Last-Event-ID")
+	if lastID == "" {
+		lastID = r.URL.Query().Get("last_event_id")
+	}
+
+	var lastEventID int64
+	if lastID != "" {
+		id, err := strconv.ParseInt(lastID, 10, 64)
+		if err != nil || id < 0 {
+			response.Error(w, http.StatusBadRequest, response.CodeBadRequest, "invalid Last-Event-ID")
+			return
+		}
+		lastEventID = id
+	}
+
+	rc := http.NewResponseController(w)
+
+	// Поток живет дольше http.Server.WriteTimeout: снимаем дедлайн для этого соединения
+	if err := rc.SetWriteDeadline(time.Time{}); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		logger.FromContext(r.Context()).Warn("stream: cannot reset write deadline", "error", err)
+	}
+
+	sub, replay, ok := h.broker.Subscribe(userID, lastEventID)
+	defer sub.Close()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no") // nginx: не буферизовать ответ
+	w.WriteHeader(http.StatusOK)
+
+	fmt.Fprintf(w, "retry: %d\n\n", streamRetry)
+
+	if !ok {
+		fmt.Fprint(w, "event: reset\ndata: {}\n\n")
+	}
+
+	for _, event := range replay {
+		if err := writeStreamEvent(w, event); err != nil {
+			return
+		}
+	}
+
+	if err := rc.Flush(); err != nil {
+		return
+	}
+
+	heartbeat := time.NewTicker(h.heartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case event, open := <-sub.C:
+			// Канал закрыт: сервер останавливается или клиент не успевал читать.
+			// EventSource переподключится и догонит пропущенное по Last-Event-ID
+			if !open {
+				return
+			}
+			if err := writeStreamEvent(w, event); err != nil {
+				return
+			}
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(w, ": heartbeat\n\n"); err != nil {
+				return
+			}
+		}
+
+		if err := rc.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+// writeStreamEvent - одно событие SSE: id, тип и JSON в одной строке data
+func writeStreamEvent(w io.Writer, event *model.TodoEvent) error {
+	data, err := json.Marshal(newTodoEventResponse(event))
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", event.ID, event.Type, data)
+	return err
+}
@@ -2,25 +2,121 @@ package handler
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
+	"net/url"
+	"slices"
 	"strconv"
+	"strings"
+	"time"
 
+	"crud-example/internal/auth"
+	"crud-example/internal/model"
+	"crud-example/internal/response"
 	"crud-example/internal/service"
+	"crud-example/internal/validate"
 )
 
 // CreateTodoRequest - DTO для создания задачи
+// Ограничения в тегах `validate` совпадают с константами service (MaxTitleLength и т.д.)
 type CreateTodoRequest struct {
-	Title       string `json:"title"`
-	Description string `json:"description"`
+	Title       string     `json:"title" validate:"required,max=255"`
+	Description string     `json:"description" validate:"max=5000"`
+	DueAt       *time.Time `json:"due_at" validate:"after=2000-01-01,before=2100-01-01"` // RFC3339, например "2025-02-01T18:00:00+03:00"
+	Priority    string     `json:"priority" validate:"oneof=low normal high urgent"`
+	Tags        []string   `json:"tags" validate:"max=20,itemmax=50"`
+	Recurrence  string     `json:"recurrence" validate:"max=255"` // RRULE, например "FREQ=WEEKLY;BYDAY=MO,TH"; нужен due_at
+	ParentID    *int64     `json:"parent_id" validate:"min=1"`    // родительская задача, null - задача верхнего уровня
+	BlockedBy   []int64    `json:"blocked_by" validate:"max=50"`  // задачи, которые нужно выполнить раньше этой
+}
+
+// newTodoInput - DTO → входные данные сервиса
+func (req CreateTodoRequest) newTodoInput() service.NewTodo {
+	return service.NewTodo{
+		Title:       req.Title,
+		Description: req.Description,
+		DueAt:       req.DueAt,
+		Priority:    req.Priority,
+		Tags:        req.Tags,
+		Recurrence:  req.Recurrence,
+		ParentID:    req.ParentID,
+		BlockedBy:   req.BlockedBy,
+	}
+}
+
+// UpdateTodoRequest - DTO для полного обновления задачи (PUT)
+type UpdateTodoRequest struct {
+	CreateTodoRequest
+	Completed bool `json:"completed"`
 }
 
 // TodoResponse - DTO для ответа с задачей
 type TodoResponse struct {
-	ID          int64  `json:"id"`
-	Title       string `json:"title"`
-	Description string `json:"description"`
-	Completed   bool   `json:"completed"`
-	CreatedAt   string `json:"created_at"`
+	ID          int64    `json:"id"`
+	Title       string   `json:"title"`
+	Description string   `json:"description"`
+	Completed   bool     `json:"completed"`
+	DueAt       *string  `json:"due_at"` // RFC3339 в UTC, null - без срока
+	Priority    string   `json:"priority"`
+	Tags        []string `json:"tags"`
+	Recurrence  *string  `json:"recurrence"` // RRULE, null - задача не повторяется
+	ParentID    *int64   `json:"parent_id"`  // null - задача верхнего уровня
+	BlockedBy   []int64  `json:"blocked_by"`
+	Version     int64    `json:"version"`
+	CreatedAt   string   `json:"created_at"`
+	UpdatedAt   string   `json:"updated_at"`
+	DeletedAt   *string  `json:"deleted_at,omitempty"` // только для задач в корзине
+}
+
+// newTodoResponse - конвертирует Entity → DTO
+func newTodoResponse(todo *model.Todo) TodoResponse {
+	resp := TodoResponse{
+		ID:          todo.ID,
+		Title:       todo.Title,
+		Description: todo.Description,
+		Completed:   todo.Completed,
+		Priority:    todo.Priority.String(),
+		Tags:        todo.Tags,
+		ParentID:    todo.ParentID,
+		BlockedBy:   todo.BlockedBy,
+		Version:     todo.Version,
+		CreatedAt:   todo.CreatedAt.Format("2006-01-02 15:04:05"),
+		UpdatedAt:   todo.UpdatedAt.Format("2006-01-02 15:04:05"),
+	}
+
+	// Срок, в отличие от служебных created_at/updated_at, клиент присылает сам,
+	// поэтому возвращаем его в том же формате RFC3339
+	if todo.DueAt != nil {
+		dueAt := todo.DueAt.Format(time.RFC3339)
+		resp.DueAt = &dueAt
+	}
+
+	if resp.Tags == nil {
+		resp.Tags = []string{}
+	}
+
+	if resp.BlockedBy == nil {
+		resp.BlockedBy = []int64{}
+	}
+
+	if todo.Recurrence != nil {
+		rule := todo.Recurrence.String()
+		resp.Recurrence = &rule
+	}
+
+	if todo.DeletedAt != nil {
+		deletedAt := todo.DeletedAt.Format("2006-01-02 15:04:05")
+		resp.DeletedAt = &deletedAt
+	}
+
+	return resp
+}
+
+// TodoListResponse - DTO для страницы списка задач
+type TodoListResponse struct {
+	Items      []TodoResponse `json:"items"`
+	NextCursor *string        `json:"next_cursor"` // null - это последняя страница
 }
 
 // TodoHandler - HTTP handler для задач
@@ -35,132 +131,561 @@ func NewTodoHandler(service *service.TodoService) *TodoHandler {
 
 // CreateTodo - POST /todos - создание задачи
 func (h *TodoHandler) CreateTodo(w http.ResponseWriter, r *http.Request) {
-	// 1. Парсим JSON
+	// 1-2. Парсим JSON и проверяем DTO по тегам `validate` (ошибки по всем полям сразу)
 	var req CreateTodoRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+	if !decodeRequest(w, r, &req) {
 		return
 	}
 
-	// 2. Валидация (базовая, остальная в сервисе)
-	if req.Title == "" {
-		http.Error(w, "Title is required", http.StatusBadRequest)
+	// 3. userID берется из JWT (кладется в контекст auth.Middleware)
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		response.Error(w, http.StatusUnauthorized, response.CodeUnauthorized, "unauthorized")
 		return
 	}
 
-	// 3. В реальном приложении userID берется из JWT
-	// Для примера используем userID = 1
-	userID := int64(1)
-
 	// 4. Вызываем сервис
-	todo, err := h.service.CreateTodo(r.Context(), userID, req.Title, req.Description)
+	todo, err := h.service.CreateTodo(r.Context(), userID, req.newTodoInput())
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeServiceError(w, r, err)
 		return
 	}
 
 	// 5. Конвертируем Entity → DTO
-	response := TodoResponse{
-		ID:          todo.ID,
-		Title:       todo.Title,
-		Description: todo.Description,
-		Completed:   todo.Completed,
-		CreatedAt:   todo.CreatedAt.Format("2006-01-02 15:04:05"),
-	}
+	resp := newTodoResponse(todo)
 
 	// 6. Возвращаем JSON
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusCreated)
-	json.NewEncoder(w).Encode(response)
+	response.JSON(w, http.StatusCreated, resp)
 }
 
 // GetTodos - GET /todos - список задач пользователя
+// Параметры: limit, cursor, completed, title, created_from, created_to,
+// due_from, due_to, priority, tag, sort (created_at, due_at, priority)
 func (h *TodoHandler) GetTodos(w http.ResponseWriter, r *http.Request) {
-	// В реальном приложении userID из JWT
-	userID := int64(1)
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		response.Error(w, http.StatusUnauthorized, response.CodeUnauthorized, "unauthorized")
+		return
+	}
 
-	todos, err := h.service.GetUserTodos(r.Context(), userID)
+	filter, err := parseTodoFilter(r.URL.Query())
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		response.Error(w, http.StatusBadRequest, response.CodeBadRequest, err.Error())
 		return
 	}
 
-	// Конвертируем в DTO
-	var response []TodoResponse
-	for _, todo := range todos {
-		response = append(response, TodoResponse{
-			ID:          todo.ID,
-			Title:       todo.Title,
-			Description: todo.Description,
-			Completed:   todo.Completed,
-			CreatedAt:   todo.CreatedAt.Format("2006-01-02 15:04:05"),
-		})
+	page, err := h.service.ListTodos(r.Context(), userID, filter)
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(response)
+	response.JSON(w, http.StatusOK, newTodoListResponse(page))
+}
+
+// GetOverdue - GET /todos/overdue - невыполненные задачи с прошедшим сроком
+// Параметры те же, что у GET /todos; сортировка по умолчанию - due_at
+func (h *TodoHandler) GetOverdue(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		response.Error(w, http.StatusUnauthorized, response.CodeUnauthorized, "unauthorized")
+		return
+	}
+
+	filter, err := parseTodoFilter(r.URL.Query())
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, response.CodeBadRequest, err.Error())
+		return
+	}
+
+	page, err := h.service.ListOverdue(r.Context(), userID, filter)
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	response.JSON(w, http.StatusOK, newTodoListResponse(page))
+}
+
+// GetTrash - GET /todos/trash - удаленные задачи пользователя
+// Параметры те же, что у GET /todos
+func (h *TodoHandler) GetTrash(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		response.Error(w, http.StatusUnauthorized, response.CodeUnauthorized, "unauthorized")
+		return
+	}
+
+	filter, err := parseTodoFilter(r.URL.Query())
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, response.CodeBadRequest, err.Error())
+		return
+	}
+
+	page, err := h.service.ListTrash(r.Context(), userID, filter)
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	response.JSON(w, http.StatusOK, newTodoListResponse(page))
+}
+
+// newTodoListResponse - конвертирует страницу задач в DTO
+func newTodoListResponse(page *model.TodoPage) TodoListResponse {
+	resp := TodoListResponse{Items: make([]TodoResponse, 0, len(page.Todos))}
+	for _, todo := range page.Todos {
+		resp.Items = append(resp.Items, newTodoResponse(todo))
+	}
+
+	if page.NextCursor != nil {
+		next := encodeCursor(page.NextCursor)
+		resp.NextCursor = &next
+	}
+
+	return resp
 }
 
 // GetTodo - GET /todos/{id} - получить задачу по ID
 func (h *TodoHandler) GetTodo(w http.ResponseWriter, r *http.Request) {
-	// Получаем ID из URL (в реальном проекте используйте router типа gorilla/mux)
-	idStr := r.URL.Query().Get("id")
-	id, err := strconv.ParseInt(idStr, 10, 64)
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		response.Error(w, http.StatusUnauthorized, response.CodeUnauthorized, "unauthorized")
+		return
+	}
+
+	// ID из пути /todos/{id}: с Go 1.22 http.ServeMux умеет wildcard-сегменты
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
 	if err != nil {
-		http.Error(w, "Invalid todo ID", http.StatusBadRequest)
+		response.Error(w, http.StatusBadRequest, response.CodeBadRequest, "invalid todo ID")
 		return
 	}
 
-	todo, err := h.repo.GetById(r.Context(), id)
+	todo, err := h.service.GetTodoByID(r.Context(), userID, id)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusNotFound)
+		writeServiceError(w, r, err)
 		return
 	}
 
-	response := TodoResponse{
-		ID:          todo.ID,
-		Title:       todo.Title,
-		Description: todo.Description,
-		Completed:   todo.Completed,
-		CreatedAt:   todo.CreatedAt.Format("2006-01-02 15:04:05"),
+	// ETag = версия задачи: клиент передает его в If-Match при изменении
+	w.Header().Set("ETag", formatETag(todo.Version))
+	response.JSON(w, http.StatusOK, newTodoResponse(todo))
+}
+
+// UpdateTodo - PUT /todos/{id} - полное обновление задачи
+func (h *TodoHandler) UpdateTodo(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		response.Error(w, http.StatusUnauthorized, response.CodeUnauthorized, "unauthorized")
+		return
+	}
+
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, response.CodeBadRequest, "invalid todo ID")
+		return
+	}
+
+	ifVersion, ok := parseIfMatch(r)
+	if !ok {
+		response.Error(w, http.StatusPreconditionFailed, response.CodePreconditionFailed, "If-Match does not match current ETag")
+		return
+	}
+
+	var req UpdateTodoRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+
+	todo, err := h.service.UpdateTodo(r.Context(), userID, id, ifVersion, req.newTodoInput(), req.Completed)
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(response)
+	w.Header().Set("ETag", formatETag(todo.Version))
+	response.JSON(w, http.StatusOK, newTodoResponse(todo))
+}
+
+// PatchTodo - PATCH /todos/{id} - частичное обновление задачи
+// Семантика JSON Merge Patch (RFC 7396): отсутствующее поле не меняется,
+// "description": null очищает описание, null для title/completed недопустим
+func (h *TodoHandler) PatchTodo(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		response.Error(w, http.StatusUnauthorized, response.CodeUnauthorized, "unauthorized")
+		return
+	}
+
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, response.CodeBadRequest, "invalid todo ID")
+		return
+	}
+
+	ifVersion, ok := parseIfMatch(r)
+	if !ok {
+		response.Error(w, http.StatusPreconditionFailed, response.CodePreconditionFailed, "If-Match does not match current ETag")
+		return
+	}
+
+	patch, err := decodeTodoPatch(r)
+	if err != nil {
+		writeRequestError(w, r, err)
+		return
+	}
+
+	todo, err := h.service.PatchTodo(r.Context(), userID, id, ifVersion, patch)
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	w.Header().Set("ETag", formatETag(todo.Version))
+	response.JSON(w, http.StatusOK, newTodoResponse(todo))
 }
 
 // CompleteTodo - POST /todos/{id}/complete - отметить как выполненную
 func (h *TodoHandler) CompleteTodo(w http.ResponseWriter, r *http.Request) {
-	idStr := r.URL.Query().Get("id")
-	id, err := strconv.ParseInt(idStr, 10, 64)
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		response.Error(w, http.StatusUnauthorized, response.CodeUnauthorized, "unauthorized")
+		return
+	}
+
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
 	if err != nil {
-		http.Error(w, "Invalid todo ID", http.StatusBadRequest)
+		response.Error(w, http.StatusBadRequest, response.CodeBadRequest, "invalid todo ID")
+		return
+	}
+
+	ifVersion, ok := parseIfMatch(r)
+	if !ok {
+		response.Error(w, http.StatusPreconditionFailed, response.CodePreconditionFailed, "If-Match does not match current ETag")
 		return
 	}
 
-	if err := h.service.CompleteTodo(r.Context(), id); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+	next, err := h.service.CompleteTodo(r.Context(), userID, id, ifVersion)
+	if err != nil {
+		writeServiceError(w, r, err)
 		return
 	}
 
-	w.WriteHeader(http.StatusOK)
-	w.Write([]byte(`{"message": "Todo completed"}`))
+	resp := CompleteTodoResponse{Message: "Todo completed"}
+	if next != nil {
+		nextResp := newTodoResponse(next)
+		resp.Next = &nextResp
+	}
+
+	response.JSON(w, http.StatusOK, resp)
+}
+
+// CompleteTodoResponse - ответ POST /todos/{id}/complete
+type CompleteTodoResponse struct {
+	Message string        `json:"message"`
+	Next    *TodoResponse `json:"next,omitempty"` // следующее повторение, если задача повторяется
 }
 
 // DeleteTodo - DELETE /todos/{id} - удалить задачу
 func (h *TodoHandler) DeleteTodo(w http.ResponseWriter, r *http.Request) {
-	idStr := r.URL.Query().Get("id")
-	id, err := strconv.ParseInt(idStr, 10, 64)
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		response.Error(w, http.StatusUnauthorized, response.CodeUnauthorized, "unauthorized")
+		return
+	}
+
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, response.CodeBadRequest, "invalid todo ID")
+		return
+	}
+
+	ifVersion, ok := parseIfMatch(r)
+	if !ok {
+		response.Error(w, http.StatusPreconditionFailed, response.CodePreconditionFailed, "If-Match does not match current ETag")
+		return
+	}
+
+	if err := h.service.DeleteTodo(r.Context(), userID, id, ifVersion); err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	response.JSON(w, http.StatusOK, map[string]string{"message": "Todo deleted"})
+}
+
+// RestoreTodo - POST /todos/{id}/restore - вернуть задачу из корзины
+func (h *TodoHandler) RestoreTodo(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		response.Error(w, http.StatusUnauthorized, response.CodeUnauthorized, "unauthorized")
+		return
+	}
+
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
 	if err != nil {
-		http.Error(w, "Invalid todo ID", http.StatusBadRequest)
+		response.Error(w, http.StatusBadRequest, response.CodeBadRequest, "invalid todo ID")
 		return
 	}
 
-	if err := h.service.DeleteTodo(r.Context(), id); err != nil {
-		http.Error(w, err.Error(), http.StatusNotFound)
+	todo, err := h.service.RestoreTodo(r.Context(), userID, id)
+	if err != nil {
+		writeServiceError(w, r, err)
 		return
 	}
 
-	w.WriteHeader(http.StatusOK)
-	w.Write([]byte(`{"message": "Todo deleted"}`))
+	w.Header().Set("ETag", formatETag(todo.Version))
+	response.JSON(w, http.StatusOK, newTodoResponse(todo))
+}
+
+// parseTodoFilter - разбирает query-параметры списка задач
+func parseTodoFilter(query url.Values) (model.TodoFilter, error) {
+	var filter model.TodoFilter
+
+	if v := query.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil {
+			return filter, fmt.Errorf("invalid limit: %q", v)
+		}
+		filter.Limit = limit
+	}
+
+	if v := query.Get("cursor"); v != "" {
+		cursor, err := decodeCursor(v)
+		if err != nil {
+			return filter, err
+		}
+		filter.After = cursor
+	}
+
+	if v := query.Get("completed"); v != "" {
+		completed, err := strconv.ParseBool(v)
+		if err != nil {
+			return filter, fmt.Errorf("invalid completed: %q", v)
+		}
+		filter.Completed = &completed
+	}
+
+	filter.TitleContains = query.Get("title")
+
+	if v := query.Get("created_from"); v != "" {
+		from, _, err := parseTimeParam(v)
+		if err != nil {
+			return filter, fmt.Errorf("invalid created_from: %q", v)
+		}
+		filter.CreatedFrom = from
+	}
+
+	if v := query.Get("created_to"); v != "" {
+		to, dateOnly, err := parseTimeParam(v)
+		if err != nil {
+			return filter, fmt.Errorf("invalid created_to: %q", v)
+		}
+		// Для даты без времени включаем весь день: created_to=2025-01-31 → < 2025-02-01
+		if dateOnly {
+			to = to.AddDate(0, 0, 1)
+		}
+		filter.CreatedTo = to
+	}
+
+	if v := query.Get("due_from"); v != "" {
+		from, _, err := parseTimeParam(v)
+		if err != nil {
+			return filter, fmt.Errorf("invalid due_from: %q", v)
+		}
+		filter.DueFrom = from
+	}
+
+	if v := query.Get("due_to"); v != "" {
+		to, dateOnly, err := parseTimeParam(v)
+		if err != nil {
+			return filter, fmt.Errorf("invalid due_to: %q", v)
+		}
+		if dateOnly {
+			to = to.AddDate(0, 0, 1)
+		}
+		filter.DueTo = to
+	}
+
+	if v := query.Get("priority"); v != "" {
+		priority, ok := model.ParsePriority(v)
+		if !ok {
+			return filter, fmt.Errorf("invalid priority: %q", v)
+		}
+		filter.Priority = &priority
+	}
+
+	// Теги хранятся в нижнем регистре (см. service.normalizeTags)
+	filter.Tag = strings.ToLower(strings.TrimSpace(query.Get("tag")))
+
+	if v := query.Get("sort"); v != "" {
+		filter.Sort = model.TodoSort(v)
+		if !filter.Sort.Valid() {
+			return filter, fmt.Errorf("invalid sort: %q (allowed: created_at, due_at, priority)", v)
+		}
+	}
+
+	return filter, nil
+}
+
+// parseTimeParam - принимает RFC3339 ("2025-01-31T10:00:00Z") или дату ("2025-01-31")
+// Колонка created_at хранит время без часового пояса (UTC), поэтому приводим к UTC
+func parseTimeParam(v string) (t time.Time, dateOnly bool, err error) {
+	if t, err = time.Parse(time.RFC3339, v); err == nil {
+		return t.UTC(), false, nil
+	}
+
+	t, err = time.Parse(time.DateOnly, v)
+	return t, true, err
+}
+
+// PatchTodoRequest - поля PATCH запроса после разбора; nil - поле не передано
+// Правила те же, что у CreateTodoRequest, но применяются только к переданным полям
+type PatchTodoRequest struct {
+	Title       *string    `json:"title" validate:"notblank,max=255"`
+	Description *string    `json:"description" validate:"max=5000"`
+	Completed   *bool      `json:"completed"`
+	DueAt       *time.Time `json:"due_at" validate:"after=2000-01-01,before=2100-01-01"`
+	Priority    *string    `json:"priority" validate:"notblank,oneof=low normal high urgent"`
+	Tags        *[]string  `json:"tags" validate:"max=20,itemmax=50"`
+	Recurrence  *string    `json:"recurrence" validate:"max=255"`
+	ParentID    *int64     `json:"parent_id" validate:"min=1"`
+	BlockedBy   *[]int64   `json:"blocked_by" validate:"max=50"`
+}
+
+// decodeTodoPatch - разбирает тело PATCH запроса в model.TodoPatch
+// Через map[string]json.RawMessage отличаем "поле не передано" от "поле равно null".
+// Ошибки по всем полям собираются в один *model.ValidationErrors
+func decodeTodoPatch(r *http.Request) (model.TodoPatch, error) {
+	var patch model.TodoPatch
+
+	var fields map[string]json.RawMessage
+	if err := decodeJSON(r.Body, &fields, false); err != nil {
+		return patch, err
+	}
+	if fields == nil {
+		return patch, model.NewValidationError("body", "must be a JSON object")
+	}
+
+	var (
+		req  PatchTodoRequest
+		errs model.ValidationErrors
+	)
+
+	isNull := func(raw json.RawMessage) bool { return string(raw) == "null" }
+	fail := func(field, message string) {
+		errs.Fields = append(errs.Fields, model.FieldError{Field: field, Message: message})
+	}
+
+	for name, raw := range fields {
+		switch name {
+		case "title":
+			if isNull(raw) {
+				fail("title", "cannot be null")
+			} else if err := json.Unmarshal(raw, &req.Title); err != nil {
+				fail("title", "must be a string")
+			}
+		case "description":
+			// null в merge-patch означает "удалить значение"
+			req.Description = new(string)
+			if !isNull(raw) {
+				if err := json.Unmarshal(raw, req.Description); err != nil {
+					fail("description", "must be a string")
+				}
+			}
+		case "completed":
+			if isNull(raw) {
+				fail("completed", "cannot be null")
+			} else if err := json.Unmarshal(raw, &req.Completed); err != nil {
+				fail("completed", "must be a boolean")
+			}
+		case "due_at":
+			// null убирает срок
+			if isNull(raw) {
+				patch.ClearDueAt = true
+			} else if err := json.Unmarshal(raw, &req.DueAt); err != nil {
+				fail("due_at", "must be an RFC3339 timestamp")
+			}
+		case "priority":
+			if isNull(raw) {
+				fail("priority", "cannot be null")
+			} else if err := json.Unmarshal(raw, &req.Priority); err != nil {
+				fail("priority", "must be a string")
+			}
+		case "tags":
+			// null, как и [], убирает все теги
+			req.Tags = &[]string{}
+			if !isNull(raw) {
+				if err := json.Unmarshal(raw, req.Tags); err != nil {
+					fail("tags", "must be an array of strings")
+				}
+			}
+		case "recurrence":
+			// null (и "") - задача больше не повторяется
+			if isNull(raw) {
+				patch.ClearRecurrence = true
+			} else if err := json.Unmarshal(raw, &req.Recurrence); err != nil {
+				fail("recurrence", "must be a string")
+			}
+		case "parent_id":
+			// null - задача становится задачей верхнего уровня
+			if isNull(raw) {
+				patch.ClearParent = true
+			} else if err := json.Unmarshal(raw, &req.ParentID); err != nil {
+				fail("parent_id", "must be an integer")
+			}
+		case "blocked_by":
+			// null, как и [], убирает все зависимости
+			req.BlockedBy = &[]int64{}
+			if !isNull(raw) {
+				if err := json.Unmarshal(raw, req.BlockedBy); err != nil {
+					fail("blocked_by", "must be an array of integers")
+				}
+			}
+		default:
+			fail(name, "unknown field")
+		}
+	}
+
+	// RRULE разбирается здесь, чтобы его ошибка попала в общий список
+	if req.Recurrence != nil && *req.Recurrence != "" {
+		rule, err := model.ParseRecurrence(*req.Recurrence)
+		if err != nil {
+			fail("recurrence", err.Error())
+		}
+		patch.Recurrence = rule
+	}
+
+	// Правила проверяем только для полей, которые удалось разобрать
+	var ruleErrs *model.ValidationErrors
+	if errors.As(validate.Struct(req), &ruleErrs) {
+		for _, f := range ruleErrs.Fields {
+			if !slices.ContainsFunc(errs.Fields, func(e model.FieldError) bool { return e.Field == f.Field }) {
+				errs.Fields = append(errs.Fields, f)
+			}
+		}
+	}
+
+	if len(errs.Fields) > 0 {
+		// Порядок полей в map случаен, а ответ должен быть стабильным
+		slices.SortFunc(errs.Fields, func(a, b model.FieldError) int { return strings.Compare(a.Field, b.Field) })
+		return patch, &errs
+	}
+
+	patch.Title = req.Title
+	patch.Description = req.Description
+	patch.Completed = req.Completed
+	patch.DueAt = req.DueAt
+	patch.Tags = req.Tags
+	patch.ParentID = req.ParentID
+	patch.BlockedBy = req.BlockedBy
+	if req.Recurrence != nil && *req.Recurrence == "" {
+		patch.ClearRecurrence = true
+	}
+	if req.Priority != nil {
+		priority, _ := model.ParsePriority(*req.Priority) // уже проверено правилом oneof
+		patch.Priority = &priority
+	}
+
+	return patch, nil
 }
@@ -0,0 +1,290 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"crud-example/internal/auth"
+	"crud-example/internal/repository"
+	"crud-example/internal/response"
+	"crud-example/internal/service"
+)
+
+var testSecret = []byte("0123456789abcdef0123456789abcdef")
+
+// testAPI - маршруты из Routes поверх сервиса с репозиторием в памяти, как в main.go
+type testAPI struct {
+	t       *testing.T
+	handler http.Handler
+}
+
+func newTestAPI(t *testing.T) *testAPI {
+	t.Helper()
+
+	repo := repository.NewInMemoryTodoRepository()
+	todos := NewTodoHandler(service.NewTodoService(repo, repository.NewInMemoryTxManager(repo)))
+
+	mux := http.NewServeMux()
+	for _, route := range Routes(todos, nil, nil, nil, nil) {
+		if !route.Public {
+			mux.Handle(route.Pattern, auth.Middleware(testSecret)(route.Handler))
+		}
+	}
+
+	return &testAPI{t: t, handler: MethodNotAllowed(mux)}
+}
+
+// do - запрос от пользователя userID; headers - пары "имя", "значение"
+func (a *testAPI) do(userID int64, method, target, body string, headers ...string) *httptest.ResponseRecorder {
+	a.t.Helper()
+
+	token, err := auth.IssueToken(testSecret, userID, time.Hour)
+	if err != nil {
+		a.t.Fatalf("IssueToken: %v", err)
+	}
+
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	req.Header.Set("Authorization", "Bearer "+token)
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	for i := 0; i+1 < len(headers); i += 2 {
+		req.Header.Set(headers[i], headers[i+1])
+	}
+
+	rec := httptest.NewRecorder()
+	a.handler.ServeHTTP(rec, req)
+	return rec
+}
+
+// create - создает задачу и возвращает ее
+func (a *testAPI) create(userID int64, body string) TodoResponse {
+	a.t.Helper()
+
+	rec := a.do(userID, http.MethodPost, "/todos", body)
+	if rec.Code != http.StatusCreated {
+		a.t.Fatalf("POST /todos: status %d, body %s", rec.Code, rec.Body)
+	}
+	return decodeTodo(a.t, rec)
+}
+
+func decodeTodo(t *testing.T, rec *httptest.ResponseRecorder) TodoResponse {
+	t.Helper()
+
+	var todo TodoResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &todo); err != nil {
+		t.Fatalf("decode todo %s: %v", rec.Body, err)
+	}
+	return todo
+}
+
+// assertError - ответ с кодом status в едином формате ошибки
+func assertError(t *testing.T, rec *httptest.ResponseRecorder, status int, code string) response.ErrorResponse {
+	t.Helper()
+
+	var body response.ErrorResponse
+	if rec.Code != status || json.Unmarshal(rec.Body.Bytes(), &body) != nil || body.Error.Code != code {
+		t.Errorf("response %d %s, want %d with code %q", rec.Code, rec.Body, status, code)
+	}
+	return body
+}
+
+func todoPath(id int64, suffix string) string {
+	return "/todos/" + strconv.FormatInt(id, 10) + suffix
+}
+
+const alice, bob int64 = 1, 2
+
+func TestPatchTodoMergePatch(t *testing.T) {
+	api := newTestAPI(t)
+	todo := api.create(alice, `{
+		"title": "Купить молоко",
+		"description": "2 литра",
+		"due_at": "2030-01-02T10:00:00Z",
+		"priority": "high",
+		"tags": ["дом"]
+	}`)
+
+	t.Run("absent fields are kept", func(t *testing.T) {
+		rec := api.do(alice, http.MethodPatch, todoPath(todo.ID, ""), `{"title":"Купить кефир"}`)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("status %d, body %s", rec.Code, rec.Body)
+		}
+
+		got := decodeTodo(t, rec)
+		if got.Title != "Купить кефир" || got.Description != "2 литра" || got.DueAt == nil ||
+			got.Priority != "high" || len(got.Tags) != 1 {
+			t.Errorf("todo = %+v, want only title changed", got)
+		}
+	})
+
+	t.Run("null clears fields", func(t *testing.T) {
+		rec := api.do(alice, http.MethodPatch, todoPath(todo.ID, ""), `{"description":null,"due_at":null,"tags":null}`)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("status %d, body %s", rec.Code, rec.Body)
+		}
+
+		got := decodeTodo(t, rec)
+		if got.Description != "" || got.DueAt != nil || len(got.Tags) != 0 || got.Title != "Купить кефир" {
+			t.Errorf("todo = %+v, want description, due_at and tags cleared", got)
+		}
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		tests := []struct {
+			name  string
+			body  string
+			field string
+		}{
+			{name: "null for a required field", body: `{"title":null}`, field: "title"},
+			{name: "wrong type", body: `{"completed":"yes"}`, field: "completed"},
+			{name: "unknown field", body: `{"owner":2}`, field: "owner"},
+			{name: "not an object", body: `["title"]`, field: "body"},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				rec := api.do(alice, http.MethodPatch, todoPath(todo.ID, ""), tt.body)
+				body := assertError(t, rec, http.StatusUnprocessableEntity, response.CodeValidation)
+				if !strings.Contains(rec.Body.String(), `"field":"`+tt.field+`"`) {
+					t.Errorf("error %+v does not mention field %s", body, tt.field)
+				}
+			})
+		}
+	})
+}
+
+func TestIfMatch(t *testing.T) {
+	api := newTestAPI(t)
+	todo := api.create(alice, `{"title":"Купить молоко"}`)
+
+	rec := api.do(alice, http.MethodGet, todoPath(todo.ID, ""), "")
+	etag := rec.Header().Get("ETag")
+	if etag != `"1"` {
+		t.Fatalf("GET ETag = %q, want \"1\"", etag)
+	}
+
+	// Правильный ETag: изменение проходит, ответ несет новый ETag
+	rec = api.do(alice, http.MethodPatch, todoPath(todo.ID, ""), `{"title":"Купить кефир"}`, "If-Match", etag)
+	if rec.Code != http.StatusOK || rec.Header().Get("ETag") != `"2"` {
+		t.Fatalf("PATCH with current ETag: status %d, ETag %q", rec.Code, rec.Header().Get("ETag"))
+	}
+
+	for _, ifMatch := range []string{etag, `W/"2"`, "2", `"abc"`} {
+		for _, req := range []struct{ method, path, body string }{
+			{http.MethodPatch, todoPath(todo.ID, ""), `{"title":"Старая версия"}`},
+			{http.MethodPut, todoPath(todo.ID, ""), `{"title":"Старая версия"}`},
+			{http.MethodPost, todoPath(todo.ID, "/complete"), ""},
+			{http.MethodDelete, todoPath(todo.ID, ""), ""},
+		} {
+			rec := api.do(alice, req.method, req.path, req.body, "If-Match", ifMatch)
+			if rec.Code != http.StatusPreconditionFailed {
+				t.Errorf("%s %s with If-Match %s: status %d, want 412", req.method, req.path, ifMatch, rec.Code)
+			}
+			assertError(t, rec, http.StatusPreconditionFailed, response.CodePreconditionFailed)
+		}
+	}
+
+	// Без If-Match версия не проверяется
+	rec = api.do(alice, http.MethodPatch, todoPath(todo.ID, ""), `{"completed":true}`)
+	if rec.Code != http.StatusOK || rec.Header().Get("ETag") != `"3"` {
+		t.Errorf("PATCH without If-Match: status %d, ETag %q", rec.Code, rec.Header().Get("ETag"))
+	}
+
+	// Задачи нет - ETag нет
+	rec = api.do(alice, http.MethodGet, todoPath(todo.ID+100, ""), "")
+	if rec.Code != http.StatusNotFound || rec.Header().Get("ETag") != "" {
+		t.Errorf("GET missing todo: status %d, ETag %q", rec.Code, rec.Header().Get("ETag"))
+	}
+}
+
+func TestForeignTodoIsNotFound(t *testing.T) {
+	api := newTestAPI(t)
+	todo := api.create(alice, `{"title":"Задача Алисы"}`)
+
+	for _, req := range []struct{ method, path, body string }{
+		{http.MethodGet, todoPath(todo.ID, ""), ""},
+		{http.MethodPatch, todoPath(todo.ID, ""), `{"title":"Задача Боба"}`},
+		{http.MethodDelete, todoPath(todo.ID, ""), ""},
+		{http.MethodPost, todoPath(todo.ID, "/complete"), ""},
+	} {
+		rec := api.do(bob, req.method, req.path, req.body)
+		assertError(t, rec, http.StatusNotFound, response.CodeNotFound)
+	}
+}
+
+func TestDeprecatedRoutes(t *testing.T) {
+	api := newTestAPI(t)
+
+	tests := []struct {
+		method    string
+		path      string
+		successor string
+	}{
+		{http.MethodGet, "/todos/get", ""},
+		{http.MethodPost, "/todos/complete", "/complete"},
+		{http.MethodDelete, "/todos/delete", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.path, func(t *testing.T) {
+			todo := api.create(alice, `{"title":"Старый клиент"}`)
+
+			rec := api.do(alice, tt.method, tt.path+"?id="+strconv.FormatInt(todo.ID, 10), "")
+			if rec.Code != http.StatusOK {
+				t.Fatalf("status %d, body %s", rec.Code, rec.Body)
+			}
+
+			wantLink := "<" + todoPath(todo.ID, tt.successor) + `>; rel="successor-version"`
+			if rec.Header().Get("Deprecation") != "true" || rec.Header().Get("Link") != wantLink {
+				t.Errorf("Deprecation %q, Link %q; want true, %s",
+					rec.Header().Get("Deprecation"), rec.Header().Get("Link"), wantLink)
+			}
+		})
+	}
+
+	// id из query попадает в path value и проверяется как обычный {id}
+	rec := api.do(alice, http.MethodGet, "/todos/get?id=abc", "")
+	assertError(t, rec, http.StatusBadRequest, response.CodeBadRequest)
+
+	// Задача действительно изменилась через старый адрес
+	todo := api.create(alice, `{"title":"Выполнить"}`)
+	api.do(alice, http.MethodPost, "/todos/complete?id="+strconv.FormatInt(todo.ID, 10), "")
+	if got := decodeTodo(t, api.do(alice, http.MethodGet, todoPath(todo.ID, ""), "")); !got.Completed {
+		t.Error("POST /todos/complete did not complete the todo")
+	}
+}
+
+func TestMethodNotAllowed(t *testing.T) {
+	api := newTestAPI(t)
+
+	tests := []struct {
+		method string
+		path   string
+		allow  []string
+	}{
+		{http.MethodDelete, "/todos", []string{"GET", "HEAD", "POST"}},
+		{http.MethodPost, "/todos/1", []string{"DELETE", "GET", "HEAD", "PATCH", "PUT"}},
+		{http.MethodGet, "/todos/1/complete", []string{"POST"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.path, func(t *testing.T) {
+			rec := api.do(alice, tt.method, tt.path, "")
+
+			assertError(t, rec, http.StatusMethodNotAllowed, response.CodeMethodNotAllowed)
+			if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
+				t.Errorf("Content-Type = %q, want application/json", ct)
+			}
+			allow := strings.Split(rec.Header().Get("Allow"), ", ")
+			if strings.Join(allow, ",") != strings.Join(tt.allow, ",") {
+				t.Errorf("Allow = %v, want %v", allow, tt.allow)
+			}
+		})
+	}
+}
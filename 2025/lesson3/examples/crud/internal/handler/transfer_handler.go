@@ -0,0 +1,177 @@
+package handler
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"net/http"
+	"sort"
+	"time"
+
+	"crud-example/internal/auth"
+	"crud-example/internal/logger"
+	"crud-example/internal/model"
+	"crud-example/internal/response"
+	"crud-example/internal/service"
+	"crud-example/internal/transfer"
+	"crud-example/internal/validate"
+)
+
+// ImportTodosResponse - результат импорта
+type ImportTodosResponse struct {
+	Imported int `json:"imported"`
+}
+
+// exportBufferSize - буфер ответа экспорта: клиенту уходят блоки, а не строки по одной
+const exportBufferSize = 32 << 10
+
+// ExportTodos - GET /todos/export?format=csv|jsonl|ics - скачать задачи файлом
+// Фильтры и сортировка - те же параметры, что у GET /todos (limit и cursor не учитываются).
+// Задачи читаются из базы страницами и сразу пишутся в ответ
+func (h *TodoHandler) ExportTodos(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		response.Error(w, http.StatusUnauthorized, response.CodeUnauthorized, "unauthorized")
+		return
+	}
+
+	format := transfer.FormatCSV
+	if v := r.URL.Query().Get("format"); v != "" {
+		if format, ok = transfer.ParseFormat(v); !ok {
+			response.Error(w, http.StatusBadRequest, response.CodeBadRequest, "invalid format (allowed: csv, jsonl, ics)")
+			return
+		}
+	}
+
+	filter, err := parseTodoFilter(r.URL.Query())
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, response.CodeBadRequest, err.Error())
+		return
+	}
+
+	// Большой файл пишется дольше http.Server.WriteTimeout: снимаем дедлайн для этого соединения
+	rc := http.NewResponseController(w)
+	if err := rc.SetWriteDeadline(time.Time{}); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		logger.FromContext(r.Context()).Warn("export: cannot reset write deadline", "error", err)
+	}
+
+	w.Header().Set("Content-Type", format.ContentType())
+	w.Header().Set("Content-Disposition", `attachment; filename="todos.`+string(format)+`"`)
+
+	// Пока ничего не отправлено, ошибку еще можно вернуть обычным JSON ответом
+	out := &countingWriter{w: w}
+	buf := bufio.NewWriterSize(out, exportBufferSize)
+
+	enc, err := transfer.NewEncoder(buf, format)
+	if err == nil {
+		err = h.service.ExportTodos(r.Context(), userID, filter, enc.Encode)
+	}
+	if err == nil {
+		err = enc.Close()
+	}
+	if err == nil {
+		err = buf.Flush()
+	}
+
+	if err == nil {
+		return
+	}
+
+	if out.n == 0 {
+		w.Header().Del("Content-Disposition")
+		writeServiceError(w, r, err)
+		return
+	}
+
+	// Часть файла уже у клиента: обрываем соединение, чтобы он не принял обрезанный файл за целый
+	logger.FromContext(r.Context()).Error("export interrupted", "error", err, "format", format)
+	panic(http.ErrAbortHandler)
+}
+
+// ImportTodos - POST /todos/import?format=csv|jsonl|ics - создать задачи из файла
+// Файл передается телом запроса; формат - параметр format или Content-Type
+// (text/csv, application/x-ndjson, text/calendar). Либо импортируются все задачи,
+// либо ни одной: ошибки возвращаются с номерами строк файла
+func (h *TodoHandler) ImportTodos(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		response.Error(w, http.StatusUnauthorized, response.CodeUnauthorized, "unauthorized")
+		return
+	}
+
+	format, ok := transfer.ParseFormat(r.URL.Query().Get("format"))
+	if !ok {
+		format, ok = transfer.FormatFromContentType(r.Header.Get("Content-Type"))
+	}
+	if !ok {
+		writeServiceError(w, r, model.NewValidationError("format",
+			"pass ?format=csv|jsonl|ics or Content-Type text/csv, application/x-ndjson, text/calendar"))
+		return
+	}
+
+	records, lineErrs, err := transfer.Decode(r.Body, format)
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			writeRequestError(w, r, err)
+			return
+		}
+		writeServiceError(w, r, err)
+		return
+	}
+
+	// Правила DTO проверяются для каждой записи, ошибки - с номером строки
+	items := make([]service.ImportTodo, 0, len(records))
+	for _, rec := range records {
+		var fieldsErr *model.ValidationErrors
+		if err := validate.Struct(rec); errors.As(err, &fieldsErr) {
+			for _, f := range fieldsErr.Fields {
+				lineErrs = append(lineErrs, model.LineError{Line: rec.Line, Field: f.Field, Message: f.Message})
+			}
+			continue
+		}
+		items = append(items, newImportTodo(rec))
+	}
+
+	if len(lineErrs) > 0 {
+		sort.SliceStable(lineErrs, func(i, j int) bool { return lineErrs[i].Line < lineErrs[j].Line })
+		writeServiceError(w, r, &model.ImportValidationError{Lines: lineErrs})
+		return
+	}
+
+	todos, err := h.service.ImportTodos(r.Context(), userID, items)
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	response.JSON(w, http.StatusCreated, ImportTodosResponse{Imported: len(todos)})
+}
+
+// newImportTodo - запись файла в данные для сервиса
+func newImportTodo(rec transfer.Record) service.ImportTodo {
+	return service.ImportTodo{
+		NewTodo: service.NewTodo{
+			Title:       rec.Title,
+			Description: rec.Description,
+			DueAt:       rec.DueAt,
+			Priority:    rec.Priority,
+			Tags:        rec.Tags,
+			Recurrence:  rec.Recurrence,
+		},
+		Completed: rec.Completed,
+		Line:      rec.Line,
+	}
+}
+
+// countingWriter - считает байты, переданные в w
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
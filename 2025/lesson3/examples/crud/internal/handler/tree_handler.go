@@ -0,0 +1,55 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"crud-example/internal/auth"
+	"crud-example/internal/model"
+	"crud-example/internal/response"
+)
+
+// TodoTreeResponse - DTO задачи с подзадачами всех уровней
+type TodoTreeResponse struct {
+	TodoResponse
+	Progress int                `json:"progress"` // процент выполнения: 100 у выполненной, иначе среднее по подзадачам
+	Subtasks []TodoTreeResponse `json:"subtasks"`
+}
+
+// newTodoTreeResponse - конвертирует дерево задач в DTO
+func newTodoTreeResponse(tree *model.TodoTree) TodoTreeResponse {
+	resp := TodoTreeResponse{
+		TodoResponse: newTodoResponse(tree.Todo),
+		Progress:     tree.Progress,
+		Subtasks:     make([]TodoTreeResponse, 0, len(tree.Subtasks)),
+	}
+
+	for _, subtask := range tree.Subtasks {
+		resp.Subtasks = append(resp.Subtasks, newTodoTreeResponse(subtask))
+	}
+
+	return resp
+}
+
+// GetTodoTree - GET /todos/{id}/tree - задача с подзадачами и процентом выполнения
+func (h *TodoHandler) GetTodoTree(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		response.Error(w, http.StatusUnauthorized, response.CodeUnauthorized, "unauthorized")
+		return
+	}
+
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, response.CodeBadRequest, "invalid todo ID")
+		return
+	}
+
+	tree, err := h.service.GetTodoTree(r.Context(), userID, id)
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	response.JSON(w, http.StatusOK, newTodoTreeResponse(tree))
+}
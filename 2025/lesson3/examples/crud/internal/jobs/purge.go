@@ -0,0 +1,62 @@
+package jobs
+
+import (
+	"context"
+	"time"
+
+	"crud-example/internal/logger"
+)
+
+// trashPurger - часть TodoService, нужная фоновой очистке корзины
+type trashPurger interface {
+	PurgeTrash(ctx context.Context, retention time.Duration) (int64, error)
+}
+
+// TrashPurger - периодически удаляет задачи, которые лежат в корзине дольше retention
+type TrashPurger struct {
+	service   trashPurger
+	retention time.Duration
+	interval  time.Duration
+}
+
+// NewTrashPurger - создает фоновую задачу очистки корзины
+func NewTrashPurger(service trashPurger, retention, interval time.Duration) *TrashPurger {
+	return &TrashPurger{
+		service:   service,
+		retention: retention,
+		interval:  interval,
+	}
+}
+
+// Run - запускает очистку сразу и затем каждые interval, пока не отменен ctx
+// Ошибки пишутся в логгер из ctx (logger.FromContext): следующая попытка будет на следующем тике
+func (p *TrashPurger) Run(ctx context.Context) {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		p.purge(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// purge - один проход очистки
+func (p *TrashPurger) purge(ctx context.Context) {
+	n, err := p.service.PurgeTrash(ctx, p.retention)
+	if err != nil {
+		// При остановке сервера запрос прерывается отменой ctx - это не ошибка
+		if ctx.Err() == nil {
+			logger.FromContext(ctx).Warn("trash purge failed", "error", err)
+		}
+		return
+	}
+
+	if n > 0 {
+		logger.FromContext(ctx).Info("trash purged", "count", n, "retention", p.retention.String())
+	}
+}
@@ -0,0 +1,87 @@
+// Package logger - структурированные логи на log/slog и логгер запроса в context.Context
+//
+// Middleware кладет в контекст логгер с request_id, дальше его достают
+// handler, сервис и репозиторий через FromContext - и все записи одного запроса
+// можно найти по request_id
+package logger
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"sync"
+)
+
+// Форматы вывода
+const (
+	FormatJSON = "json"
+	FormatText = "text"
+)
+
+// New - логгер в w в формате format (json или text) с минимальным уровнем level
+// (debug, info, warn, error)
+func New(w io.Writer, format, level string) (*slog.Logger, error) {
+	var lvl slog.Level
+	if err := lvl.UnmarshalText([]byte(level)); err != nil {
+		return nil, fmt.Errorf("invalid log level %q", level)
+	}
+
+	opts := &slog.HandlerOptions{Level: lvl}
+
+	switch format {
+	case FormatJSON:
+		return slog.New(slog.NewJSONHandler(w, opts)), nil
+	case FormatText:
+		return slog.New(slog.NewTextHandler(w, opts)), nil
+	}
+
+	return nil, fmt.Errorf("invalid log format %q (allowed: json, text)", format)
+}
+
+type loggerKey struct{}
+
+// WithContext - кладет логгер в контекст
+func WithContext(ctx context.Context, l *slog.Logger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, l)
+}
+
+// FromContext - логгер из контекста; slog.Default(), если его там нет
+func FromContext(ctx context.Context) *slog.Logger {
+	if l, ok := ctx.Value(loggerKey{}).(*slog.Logger); ok {
+		return l
+	}
+	return slog.Default()
+}
+
+// With - добавляет атрибуты к логгеру в контексте
+// Внутри запроса они попадут и в итоговую запись о нем (см. Middleware):
+//
+//	ctx = logger.With(ctx, "user_id", userID)
+func With(ctx context.Context, args ...any) context.Context {
+	if attrs, ok := ctx.Value(requestAttrsKey{}).(*requestAttrs); ok {
+		attrs.add(args)
+	}
+	return WithContext(ctx, FromContext(ctx).With(args...))
+}
+
+type requestAttrsKey struct{}
+
+// requestAttrs - атрибуты, добавленные через With по ходу запроса
+// Middleware видит только свой контекст, поэтому собирает их через общий указатель
+type requestAttrs struct {
+	mu   sync.Mutex
+	args []any
+}
+
+func (a *requestAttrs) add(args []any) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.args = append(a.args, args...)
+}
+
+func (a *requestAttrs) list() []any {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.args
+}
@@ -0,0 +1,146 @@
+package logger
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// RequestIDHeader - заголовок с ID запроса
+// Пришедший от клиента (или балансировщика) ID сохраняется, иначе генерируется новый
+const RequestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength - более длинные ID от клиента не принимаем (это попадает в логи)
+const maxRequestIDLength = 128
+
+type requestIDKey struct{}
+
+// RequestIDFromContext - ID текущего запроса ("" вне запроса)
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// Middleware - назначает запросу X-Request-ID, кладет в контекст логгер с request_id
+// и после ответа пишет одну запись: метод, путь, статус, размер, время и пользователь
+func Middleware(base *slog.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+
+			requestID := r.Header.Get(RequestIDHeader)
+			if !validRequestID(requestID) {
+				requestID = newRequestID()
+			}
+			w.Header().Set(RequestIDHeader, requestID)
+
+			attrs := &requestAttrs{}
+			ctx := context.WithValue(r.Context(), requestIDKey{}, requestID)
+			ctx = context.WithValue(ctx, requestAttrsKey{}, attrs)
+			ctx = WithContext(ctx, base.With("request_id", requestID))
+
+			rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+
+			// panic(http.ErrAbortHandler) - handler оборвал уже начатый ответ (например, экспорт).
+			// Запрос все равно попадает в лог, а panic идет дальше: соединение закрывает net/http
+			defer func() {
+				p := recover()
+				if p != nil && p != http.ErrAbortHandler {
+					panic(p)
+				}
+
+				logRequest(ctx, base, r, rec, attrs, start, p != nil)
+
+				if p != nil {
+					panic(p)
+				}
+			}()
+
+			next.ServeHTTP(rec, r.WithContext(ctx))
+		})
+	}
+}
+
+// logRequest - одна запись о запросе после ответа
+// aborted - ответ оборван на середине (http.ErrAbortHandler), клиент получил неполное тело
+func logRequest(ctx context.Context, base *slog.Logger, r *http.Request, rec *statusRecorder,
+	attrs *requestAttrs, start time.Time, aborted bool) {
+	level := slog.LevelInfo
+	switch {
+	case aborted || rec.status >= 500:
+		level = slog.LevelError
+	case rec.status >= 400:
+		level = slog.LevelWarn
+	}
+
+	args := []any{
+		"request_id", RequestIDFromContext(ctx),
+		"method", r.Method,
+		"path", r.URL.Path,
+		"status", rec.status,
+		"bytes", rec.bytes,
+		"duration_ms", float64(time.Since(start).Microseconds()) / 1000,
+		"remote_addr", r.RemoteAddr,
+	}
+	if aborted {
+		args = append(args, "aborted", true)
+	}
+	args = append(args, attrs.list()...)
+
+	base.Log(ctx, level, "http request", args...)
+}
+
+// statusRecorder - запоминает статус и размер ответа
+type statusRecorder struct {
+	http.ResponseWriter
+	status      int
+	bytes       int
+	wroteHeader bool
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	if !r.wroteHeader {
+		r.status = status
+		r.wroteHeader = true
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *statusRecorder) Write(b []byte) (int, error) {
+	r.wroteHeader = true
+	n, err := r.ResponseWriter.Write(b)
+	r.bytes += n
+	return n, err
+}
+
+// Unwrap - дает http.ResponseController добраться до Flush и дедлайнов исходного writer
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+// validRequestID - непустой, не слишком длинный и только из безопасных символов
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+
+	for _, c := range id {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9', c == '-', c == '_', c == '.':
+		default:
+			return false
+		}
+	}
+
+	return true
+}
+
+// newRequestID - 16 случайных байт в hex
+func newRequestID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,60 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestMiddlewareLogsAbortedRequest - оборванный ответ попадает в лог, а panic доходит до net/http
+func TestMiddlewareLogsAbortedRequest(t *testing.T) {
+	var buf bytes.Buffer
+	base := slog.New(slog.NewJSONHandler(&buf, nil))
+
+	h := Middleware(base)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("часть файла"))
+		panic(http.ErrAbortHandler)
+	}))
+
+	func() {
+		defer func() {
+			if p := recover(); p != http.ErrAbortHandler {
+				t.Errorf("panic = %v, want http.ErrAbortHandler", p)
+			}
+		}()
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/todos/export", nil))
+	}()
+
+	var entry map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("log entry %q: %v", buf.String(), err)
+	}
+	if entry["msg"] != "http request" || entry["level"] != "ERROR" || entry["aborted"] != true ||
+		entry["path"] != "/todos/export" || entry["bytes"] != float64(len("часть файла")) {
+		t.Errorf("log entry = %v", entry)
+	}
+}
+
+// TestMiddlewareKeepsOtherPanics - чужие panic не логируются как запрос и не подменяются
+func TestMiddlewareKeepsOtherPanics(t *testing.T) {
+	var buf bytes.Buffer
+	h := Middleware(slog.New(slog.NewJSONHandler(&buf, nil)))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	}))
+
+	func() {
+		defer func() {
+			if p := recover(); p != "boom" {
+				t.Errorf("panic = %v, want boom", p)
+			}
+		}()
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	}()
+
+	if buf.Len() != 0 {
+		t.Errorf("unexpected log: %s", buf.String())
+	}
+}
@@ -0,0 +1,146 @@
+// Package metrics - метрики Prometheus: HTTP запросы, вызовы репозитория и пул соединений
+//
+// Все метрики регистрируются в собственном реестре (не в prometheus.DefaultRegisterer),
+// поэтому несколько экземпляров Metrics (например, в тестах) не конфликтуют
+package metrics
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// namespace - префикс имен метрик сервиса
+const namespace = "crud"
+
+// queryBuckets - границы гистограммы длительности запросов к БД, секунды
+// Запросы обычно быстрее HTTP обработчиков, поэтому шкала мельче prometheus.DefBuckets
+var queryBuckets = []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5}
+
+// Metrics - реестр метрик сервера и обработчик GET /metrics
+type Metrics struct {
+	registry      *prometheus.Registry
+	handler       http.Handler
+	httpRequests  *prometheus.CounterVec
+	httpDuration  *prometheus.HistogramVec
+	queryDuration *prometheus.HistogramVec
+}
+
+// New - создает метрики и регистрирует сборщики среды выполнения Go и процесса
+// Если db не nil, на каждый сбор метрик читается db.Stats() пула соединений:
+// go_sql_open_connections, go_sql_in_use_connections, go_sql_idle_connections,
+// go_sql_wait_count_total, go_sql_wait_duration_seconds_total и другие
+func New(db *sql.DB) *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		httpRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "http_requests_total",
+			Help:      "Количество HTTP запросов по маршруту, методу и коду ответа.",
+		}, []string{"route", "method", "code"}),
+		httpDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "http_request_duration_seconds",
+			Help:      "Длительность обработки HTTP запросов по маршруту и методу.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"route", "method"}),
+		queryDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "repository_query_duration_seconds",
+			Help:      "Длительность вызовов методов репозитория задач.",
+			Buckets:   queryBuckets,
+		}, []string{"method"}),
+	}
+
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.httpRequests,
+		m.httpDuration,
+		m.queryDuration,
+	)
+
+	if db != nil {
+		m.registry.MustRegister(collectors.NewDBStatsCollector(db, "todos"))
+	}
+
+	m.handler = promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{Registry: m.registry})
+
+	return m
+}
+
+// ServeHTTP - GET /metrics - метрики в текстовом формате Prometheus
+func (m *Metrics) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	m.handler.ServeHTTP(w, r)
+}
+
+// InstrumentRoute - считает запросы и их длительность для маршрута pattern
+// Метка route - путь из шаблона ServeMux ("/todos/{id}"), а не из запроса:
+// иначе каждый ID задачи создал бы отдельный временной ряд
+func (m *Metrics) InstrumentRoute(pattern string, next http.Handler) http.Handler {
+	route := pattern
+	if _, path, ok := strings.Cut(pattern, " "); ok {
+		route = path
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+
+		// Оборванный ответ (panic(http.ErrAbortHandler)) тоже считается, panic идет дальше
+		defer func() {
+			p := recover()
+			if p != nil && p != http.ErrAbortHandler {
+				panic(p)
+			}
+
+			m.httpRequests.WithLabelValues(route, r.Method, strconv.Itoa(rec.status)).Inc()
+			m.httpDuration.WithLabelValues(route, r.Method).Observe(time.Since(start).Seconds())
+
+			if p != nil {
+				panic(p)
+			}
+		}()
+
+		next.ServeHTTP(rec, r)
+	})
+}
+
+// statusRecorder - запоминает код ответа
+// Не promhttp.InstrumentHandler*: их обертка не отдает исходный writer через Unwrap,
+// и http.ResponseController (Flush и дедлайны для SSE) через нее не работает
+type statusRecorder struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	if !r.wroteHeader {
+		r.status = status
+		r.wroteHeader = true
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *statusRecorder) Write(b []byte) (int, error) {
+	r.wroteHeader = true
+	return r.ResponseWriter.Write(b)
+}
+
+// Unwrap - дает http.ResponseController добраться до исходного writer
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+// ObserveQuery - записывает длительность вызова метода репозитория
+// Реализует repository.QueryObserver
+func (m *Metrics) ObserveQuery(method string, d time.Duration) {
+	m.queryDuration.WithLabelValues(method).Observe(d.Seconds())
+}
@@ -0,0 +1,147 @@
+package migrate
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+// migrationsFS - файлы в каталоге migrations
+func migrationsFS(files ...string) fstest.MapFS {
+	fsys := fstest.MapFS{}
+	for _, name := range files {
+		fsys["migrations/"+name] = &fstest.MapFile{Data: []byte("-- " + name)}
+	}
+	return fsys
+}
+
+func TestLoad(t *testing.T) {
+	migrations, err := load(migrationsFS(
+		"0010_tags.up.sql", "0010_tags.down.sql",
+		"0002_version.down.sql", "0002_version.up.sql",
+		"0001_init.up.sql", "0001_init.down.sql",
+	))
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+
+	// Версии сравниваются как числа, а не как строки
+	want := []struct {
+		version int64
+		name    string
+	}{{1, "init"}, {2, "version"}, {10, "tags"}}
+	if len(migrations) != len(want) {
+		t.Fatalf("load returned %d migrations, want %d", len(migrations), len(want))
+	}
+	for i, w := range want {
+		mig := migrations[i]
+		if mig.Version != w.version || mig.Name != w.name {
+			t.Errorf("migration %d = %04d_%s, want %04d_%s", i, mig.Version, mig.Name, w.version, w.name)
+		}
+
+		// Содержимое файла - "-- " и его имя: up и down не перепутаны между версиями
+		base := fmt.Sprintf("-- %04d_%s", w.version, w.name)
+		if mig.Up != base+".up.sql" || mig.Down != base+".down.sql" {
+			t.Errorf("migration %s: up %q, down %q", mig.Name, mig.Up, mig.Down)
+		}
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		files fstest.MapFS
+		want  string
+	}{
+		{
+			name:  "missing down",
+			files: migrationsFS("0001_init.up.sql", "0002_tags.up.sql", "0002_tags.down.sql"),
+			want:  "migration 0001_init: both up and down files are required",
+		},
+		{
+			name:  "missing up",
+			files: migrationsFS("0001_init.down.sql"),
+			want:  "both up and down files are required",
+		},
+		{
+			name:  "unknown suffix",
+			files: migrationsFS("0001_init.up.sql", "0001_init.down.sql", "README.md"),
+			want:  "migration README.md: expected .up.sql or .down.sql suffix",
+		},
+		{
+			name:  "no name",
+			files: migrationsFS("0001.up.sql"),
+			want:  "expected NNNN_name prefix",
+		},
+		{
+			name:  "invalid version",
+			files: migrationsFS("first_init.up.sql"),
+			want:  `invalid version "first"`,
+		},
+		{
+			name:  "zero version",
+			files: migrationsFS("0000_init.up.sql"),
+			want:  `invalid version "0000"`,
+		},
+		{
+			name:  "conflicting names",
+			files: migrationsFS("0001_init.up.sql", "0001_start.down.sql"),
+			want:  `migration 0001: conflicting names`,
+		},
+		{
+			name:  "no migrations directory",
+			files: fstest.MapFS{},
+			want:  "migrations",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := load(tt.files)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("load() = %v, want error containing %q", err, tt.want)
+			}
+		})
+	}
+}
+
+// TestEmbeddedMigrations - миграции, вшитые в бинарник, загружаются и идут без пропусков
+func TestEmbeddedMigrations(t *testing.T) {
+	migrations, err := load(migrationFiles)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+
+	for i, mig := range migrations {
+		if mig.Version != int64(i+1) {
+			t.Errorf("migration %d has version %d, want %d", i, mig.Version, i+1)
+		}
+	}
+}
+
+func TestCutDirection(t *testing.T) {
+	tests := []struct {
+		fileName      string
+		wantBase      string
+		wantDirection string
+		wantOK        bool
+	}{
+		{"0001_init.up.sql", "0001_init", "up", true},
+		{"0001_init.down.sql", "0001_init", "down", true},
+		{"0003_todos_version.up.sql", "0003_todos_version", "up", true},
+		{"0001_init.sql", "", "", false},
+		{"0001_init.up.SQL", "", "", false},
+		{"0001_init.up.sql.bak", "", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.fileName, func(t *testing.T) {
+			base, direction, ok := cutDirection(tt.fileName)
+			if base != tt.wantBase || direction != tt.wantDirection || ok != tt.wantOK {
+				t.Errorf("cutDirection(%q) = %q, %q, %v; want %q, %q, %v",
+					tt.fileName, base, direction, ok, tt.wantBase, tt.wantDirection, tt.wantOK)
+			}
+		})
+	}
+}
@@ -0,0 +1 @@
+DROP INDEX IF EXISTS idx_todos_user_created;
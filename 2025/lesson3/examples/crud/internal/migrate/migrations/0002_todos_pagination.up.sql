@@ -0,0 +1,2 @@
+-- Индекс для keyset-пагинации: WHERE user_id = ? ORDER BY created_at DESC, id DESC
+CREATE INDEX IF NOT EXISTS idx_todos_user_created ON todos(user_id, created_at DESC, id DESC);
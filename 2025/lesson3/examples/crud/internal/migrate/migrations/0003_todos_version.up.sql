@@ -0,0 +1,3 @@
+-- Версия строки для оптимистичной блокировки (ETag / If-Match)
+-- Каждый UPDATE увеличивает version на 1
+ALTER TABLE todos ADD COLUMN IF NOT EXISTS version INTEGER NOT NULL DEFAULT 1;
@@ -0,0 +1,4 @@
+-- Без колонки deleted_at задачи из корзины снова стали бы видимыми, поэтому удаляем их
+DELETE FROM todos WHERE deleted_at IS NOT NULL;
+DROP INDEX IF EXISTS idx_todos_deleted_at;
+ALTER TABLE todos DROP COLUMN IF EXISTS deleted_at;
@@ -0,0 +1,6 @@
+-- Мягкое удаление: DELETE только проставляет deleted_at, задача попадает в корзину
+-- Фоновая задача окончательно удаляет строки старше todos.trash_retention
+ALTER TABLE todos ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP NULL;
+
+-- Корзина пользователя и поиск строк для очистки
+CREATE INDEX IF NOT EXISTS idx_todos_deleted_at ON todos(deleted_at) WHERE deleted_at IS NOT NULL;
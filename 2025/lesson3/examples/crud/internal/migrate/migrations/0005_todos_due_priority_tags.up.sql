@@ -0,0 +1,24 @@
+-- Срок выполнения и приоритет (1 - low, 2 - normal, 3 - high, 4 - urgent)
+ALTER TABLE todos ADD COLUMN IF NOT EXISTS due_at TIMESTAMP NULL;
+ALTER TABLE todos ADD COLUMN IF NOT EXISTS priority SMALLINT NOT NULL DEFAULT 2
+    CONSTRAINT todos_priority_check CHECK (priority BETWEEN 1 AND 4);
+
+-- Сортировка по сроку и по приоритету (см. model.TodoSort)
+CREATE INDEX IF NOT EXISTS idx_todos_user_due ON todos(user_id, due_at, id);
+CREATE INDEX IF NOT EXISTS idx_todos_user_priority ON todos(user_id, priority DESC, id DESC);
+
+-- Теги: у каждого пользователя свой набор, связь с задачами многие-ко-многим
+CREATE TABLE IF NOT EXISTS tags (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name VARCHAR(50) NOT NULL,
+    UNIQUE (user_id, name)
+);
+
+CREATE TABLE IF NOT EXISTS todo_tags (
+    todo_id INTEGER NOT NULL REFERENCES todos(id) ON DELETE CASCADE,
+    tag_id INTEGER NOT NULL REFERENCES tags(id) ON DELETE CASCADE,
+    PRIMARY KEY (todo_id, tag_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_todo_tags_tag ON todo_tags(tag_id);
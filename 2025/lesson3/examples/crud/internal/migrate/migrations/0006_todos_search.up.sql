@@ -0,0 +1,9 @@
+-- Полнотекстовый поиск: заголовок важнее описания (вес A против B)
+-- Конфигурация russian стеммит русские слова, а латиницу обрабатывает english_stem
+ALTER TABLE todos ADD COLUMN IF NOT EXISTS search_vector tsvector
+    GENERATED ALWAYS AS (
+        setweight(to_tsvector('russian', coalesce(title, '')), 'A') ||
+        setweight(to_tsvector('russian', coalesce(description, '')), 'B')
+    ) STORED;
+
+CREATE INDEX IF NOT EXISTS idx_todos_search ON todos USING GIN (search_vector);
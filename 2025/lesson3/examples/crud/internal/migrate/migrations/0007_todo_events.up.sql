@@ -0,0 +1,14 @@
+-- История изменений задач (аудит)
+-- before/after содержат только изменившиеся поля; событие пишется в той же транзакции, что и изменение
+CREATE TABLE IF NOT EXISTS todo_events (
+    id BIGSERIAL PRIMARY KEY,
+    todo_id INTEGER NOT NULL REFERENCES todos(id) ON DELETE CASCADE,
+    actor_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    type VARCHAR(20) NOT NULL,
+    version INTEGER NOT NULL, -- версия задачи после изменения
+    before JSONB,
+    after JSONB,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_todo_events_todo ON todo_events(todo_id, id);
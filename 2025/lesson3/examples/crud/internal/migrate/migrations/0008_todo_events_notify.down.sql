@@ -0,0 +1,2 @@
+DROP TRIGGER IF EXISTS todo_events_notify ON todo_events;
+DROP FUNCTION IF EXISTS notify_todo_event();
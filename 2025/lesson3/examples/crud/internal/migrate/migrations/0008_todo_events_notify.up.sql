@@ -0,0 +1,33 @@
+-- Каждая запись истории уходит в канал todo_events через pg_notify: реплики API слушают
+-- его (LISTEN) и раздают события своим подписчикам GET /todos/stream.
+-- Уведомление доставляется только после COMMIT и не доставляется при ROLLBACK.
+-- Payload ограничен 8000 байт: большое событие отправляется без данных, только с id,
+-- и слушатель читает его из todo_events сам
+CREATE OR REPLACE FUNCTION notify_todo_event() RETURNS trigger AS $$
+DECLARE
+    payload TEXT;
+BEGIN
+    payload := json_build_object(
+        'id', NEW.id,
+        'todo_id', NEW.todo_id,
+        'actor_id', NEW.actor_id,
+        'type', NEW.type,
+        'version', NEW.version,
+        'before', NEW.before,
+        'after', NEW.after,
+        'created_at', NEW.created_at
+    )::text;
+
+    IF octet_length(payload) > 7900 THEN
+        payload := json_build_object('id', NEW.id, 'actor_id', NEW.actor_id)::text;
+    END IF;
+
+    PERFORM pg_notify('todo_events', payload);
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS todo_events_notify ON todo_events;
+CREATE TRIGGER todo_events_notify
+    AFTER INSERT ON todo_events
+    FOR EACH ROW EXECUTE FUNCTION notify_todo_event();
@@ -0,0 +1,3 @@
+-- Правило повторения задачи - строка RRULE (FREQ=WEEKLY;BYDAY=MO), NULL - задача не повторяется.
+-- Разбирается и проверяется в model.ParseRecurrence
+ALTER TABLE todos ADD COLUMN IF NOT EXISTS recurrence VARCHAR(255) NULL;
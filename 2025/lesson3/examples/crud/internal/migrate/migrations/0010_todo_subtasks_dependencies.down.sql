@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS todo_dependencies;
+DROP INDEX IF EXISTS idx_todos_parent;
+ALTER TABLE todos DROP COLUMN IF EXISTS parent_id;
@@ -0,0 +1,16 @@
+-- Подзадачи: parent_id ссылается на родительскую задачу того же пользователя.
+-- При окончательном удалении родителя (PurgeDeleted) подзадачи становятся задачами верхнего уровня
+ALTER TABLE todos ADD COLUMN IF NOT EXISTS parent_id INTEGER NULL REFERENCES todos(id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_todos_parent ON todos(parent_id) WHERE parent_id IS NOT NULL;
+
+-- Зависимости: задачу todo_id нельзя выполнить, пока не выполнена blocked_by_id.
+-- Циклы проверяет сервис (TodoService), база запрещает только ссылку задачи на себя
+CREATE TABLE IF NOT EXISTS todo_dependencies (
+    todo_id INTEGER NOT NULL REFERENCES todos(id) ON DELETE CASCADE,
+    blocked_by_id INTEGER NOT NULL REFERENCES todos(id) ON DELETE CASCADE,
+    PRIMARY KEY (todo_id, blocked_by_id),
+    CONSTRAINT todo_dependencies_self_check CHECK (todo_id <> blocked_by_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_todo_dependencies_blocked_by ON todo_dependencies(blocked_by_id);
@@ -0,0 +1,151 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Доменные ошибки (sentinel errors)
+// Репозиторий и сервис оборачивают их через fmt.Errorf("...: %w", err),
+// а HTTP слой проверяет через errors.Is и выбирает статус ответа
+var (
+	// ErrNotFound - сущность не найдена
+	ErrNotFound = errors.New("not found")
+	// ErrValidation - входные данные не прошли валидацию
+	ErrValidation = errors.New("validation failed")
+	// ErrConflict - операция конфликтует с текущим состоянием (например, дубликат)
+	ErrConflict = errors.New("conflict")
+	// ErrForbidden - у пользователя нет прав на операцию
+	ErrForbidden = errors.New("forbidden")
+)
+
+// ErrTodoNotFound - задача не найдена (или принадлежит другому пользователю)
+// errors.Is(ErrTodoNotFound, ErrNotFound) == true
+var ErrTodoNotFound = fmt.Errorf("todo %w", ErrNotFound)
+
+// ErrVersionConflict - задачу изменили после того, как клиент ее прочитал
+// (версия в If-Match или прочитанная версия не совпадает с текущей)
+var ErrVersionConflict = fmt.Errorf("todo version %w", ErrConflict)
+
+// ValidationError - ошибка валидации конкретного поля
+// errors.Is(err, ErrValidation) == true
+type ValidationError struct {
+	Field   string
+	Message string
+}
+
+// NewValidationError - создает ошибку валидации поля
+func NewValidationError(field, message string) *ValidationError {
+	return &ValidationError{Field: field, Message: message}
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Field, e.Message)
+}
+
+// Unwrap - позволяет проверять ошибку через errors.Is(err, ErrValidation)
+func (e *ValidationError) Unwrap() error {
+	return ErrValidation
+}
+
+// FieldError - ошибка валидации одного поля в ответе API
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ValidationErrors - ошибки валидации сразу нескольких полей запроса
+// errors.Is(err, ErrValidation) == true
+type ValidationErrors struct {
+	Fields []FieldError
+}
+
+func (e *ValidationErrors) Error() string {
+	messages := make([]string, 0, len(e.Fields))
+	for _, f := range e.Fields {
+		messages = append(messages, f.Field+": "+f.Message)
+	}
+	return strings.Join(messages, "; ")
+}
+
+// Unwrap - позволяет проверять ошибку через errors.Is(err, ErrValidation)
+func (e *ValidationErrors) Unwrap() error {
+	return ErrValidation
+}
+
+// ItemError - ошибка валидации одного элемента пакетного запроса
+type ItemError struct {
+	Index   int    `json:"index"`
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// BatchValidationError - ошибки валидации элементов пакетного запроса
+// errors.Is(err, ErrValidation) == true
+type BatchValidationError struct {
+	Items []ItemError
+}
+
+func (e *BatchValidationError) Error() string {
+	return fmt.Sprintf("%d invalid item(s) in batch", len(e.Items))
+}
+
+// Unwrap - позволяет проверять ошибку через errors.Is(err, ErrValidation)
+func (e *BatchValidationError) Unwrap() error {
+	return ErrValidation
+}
+
+// LineError - ошибка в одной записи импортируемого файла
+// Line - номер строки файла, с которой начинается запись (с 1)
+type LineError struct {
+	Line    int    `json:"line"`
+	Field   string `json:"field,omitempty"` // пусто, если запись не удалось разобрать целиком
+	Message string `json:"message"`
+}
+
+// ImportValidationError - ошибки в записях импортируемого файла; ничего не импортировано
+// errors.Is(err, ErrValidation) == true
+type ImportValidationError struct {
+	Lines []LineError
+}
+
+func (e *ImportValidationError) Error() string {
+	return fmt.Sprintf("%d error(s) in imported file", len(e.Lines))
+}
+
+// Unwrap - позволяет проверять ошибку через errors.Is(err, ErrValidation)
+func (e *ImportValidationError) Unwrap() error {
+	return ErrValidation
+}
+
+// MissingTodosError - часть задач из пакетного запроса не найдена (или чужие)
+// errors.Is(err, ErrTodoNotFound) == true
+type MissingTodosError struct {
+	IDs []int64
+}
+
+func (e *MissingTodosError) Error() string {
+	return fmt.Sprintf("todos %v not found", e.IDs)
+}
+
+// Unwrap - позволяет проверять ошибку через errors.Is(err, ErrTodoNotFound)
+func (e *MissingTodosError) Unwrap() error {
+	return ErrTodoNotFound
+}
+
+// BlockedTodoError - задачу нельзя выполнить, пока не выполнены задачи, от которых она зависит
+// errors.Is(err, ErrConflict) == true
+type BlockedTodoError struct {
+	ID        int64
+	BlockedBy []int64 // невыполненные задачи из Todo.BlockedBy
+}
+
+func (e *BlockedTodoError) Error() string {
+	return fmt.Sprintf("todo %d is blocked by open todos %v", e.ID, e.BlockedBy)
+}
+
+// Unwrap - позволяет проверять ошибку через errors.Is(err, ErrConflict)
+func (e *BlockedTodoError) Unwrap() error {
+	return ErrConflict
+}
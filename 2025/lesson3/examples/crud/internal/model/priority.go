@@ -0,0 +1,51 @@
+package model
+
+// Priority - приоритет задачи
+// В базе хранится числом (SMALLINT), чтобы ORDER BY priority DESC сортировал от важных к неважным,
+// а в API передается строкой: "low", "normal", "high", "urgent"
+type Priority int16
+
+// Допустимые значения Priority
+const (
+	PriorityLow    Priority = 1
+	PriorityNormal Priority = 2
+	PriorityHigh   Priority = 3
+	PriorityUrgent Priority = 4
+)
+
+// priorityNames - имена приоритетов в API
+var priorityNames = map[Priority]string{
+	PriorityLow:    "low",
+	PriorityNormal: "normal",
+	PriorityHigh:   "high",
+	PriorityUrgent: "urgent",
+}
+
+// ParsePriority - "high" → PriorityHigh; ok == false для неизвестного имени
+func ParsePriority(name string) (Priority, bool) {
+	for p, n := range priorityNames {
+		if n == name {
+			return p, true
+		}
+	}
+	return 0, false
+}
+
+// Valid - значение входит в перечисление
+func (p Priority) Valid() bool {
+	_, ok := priorityNames[p]
+	return ok
+}
+
+// String - имя приоритета для API
+func (p Priority) String() string {
+	if name, ok := priorityNames[p]; ok {
+		return name
+	}
+	return "unknown"
+}
+
+// PriorityNames - допустимые имена по возрастанию приоритета (для сообщений об ошибках)
+func PriorityNames() []string {
+	return []string{"low", "normal", "high", "urgent"}
+}
@@ -0,0 +1,371 @@
+package model
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Frequency - период повторения (FREQ в RRULE)
+type Frequency string
+
+// Поддерживаемые значения Frequency
+const (
+	FreqDaily   Frequency = "DAILY"
+	FreqWeekly  Frequency = "WEEKLY"
+	FreqMonthly Frequency = "MONTHLY"
+)
+
+// MaxRecurrenceInterval - наибольший INTERVAL
+const MaxRecurrenceInterval = 1000
+
+// WeekdayNum - элемент BYDAY: день недели и, для MONTHLY, его номер в месяце
+// N == 0 - каждый такой день; 2 - второй в месяце; -1 - последний
+type WeekdayNum struct {
+	N   int
+	Day time.Weekday
+}
+
+// Recurrence - правило повторения задачи: подмножество RRULE из RFC 5545
+// Поддерживаются FREQ=DAILY|WEEKLY|MONTHLY, INTERVAL, BYDAY, COUNT и UNTIL.
+// Первое повторение - срок самой задачи (DTSTART в терминах RFC), следующие считаются
+// от него в UTC с тем же временем суток. Правило не меняется после создания:
+// изменения - это новое значение
+type Recurrence struct {
+	Freq     Frequency
+	Interval int          // 1 - каждый день (неделю, месяц), 2 - через один и т.д.
+	ByDay    []WeekdayNum // пусто - день недели (число месяца) берется из срока задачи
+	Count    int          // сколько повторений осталось, включая текущую задачу; 0 - без ограничения
+	Until    *time.Time   // последний допустимый срок (UTC); nil - без ограничения
+}
+
+// weekdayCodes - дни недели в RRULE
+var weekdayCodes = map[string]time.Weekday{
+	"MO": time.Monday,
+	"TU": time.Tuesday,
+	"WE": time.Wednesday,
+	"TH": time.Thursday,
+	"FR": time.Friday,
+	"SA": time.Saturday,
+	"SU": time.Sunday,
+}
+
+// untilLayout - UNTIL в UTC; UNTIL без времени (20250301) означает конец этого дня
+const untilLayout = "20060102T150405Z"
+
+// ParseRecurrence - разбирает строку RRULE ("FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,TH;COUNT=10")
+// Префикс "RRULE:" необязателен. Неподдерживаемые части (BYMONTHDAY, BYSETPOS...) - ошибка
+func ParseRecurrence(rule string) (*Recurrence, error) {
+	rule = strings.TrimSpace(rule)
+	rule = strings.TrimPrefix(strings.TrimPrefix(rule, "RRULE:"), "rrule:")
+	if rule == "" {
+		return nil, errors.New("is empty")
+	}
+
+	r := &Recurrence{Interval: 1}
+	seen := make(map[string]bool)
+
+	for _, part := range strings.Split(rule, ";") {
+		name, value, ok := strings.Cut(part, "=")
+		name = strings.ToUpper(strings.TrimSpace(name))
+		value = strings.ToUpper(strings.TrimSpace(value))
+		if !ok || name == "" || value == "" {
+			return nil, fmt.Errorf("invalid part %q (expected NAME=VALUE)", part)
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("%s is repeated", name)
+		}
+		seen[name] = true
+
+		switch name {
+		case "FREQ":
+			r.Freq = Frequency(value)
+			if r.Freq != FreqDaily && r.Freq != FreqWeekly && r.Freq != FreqMonthly {
+				return nil, fmt.Errorf("FREQ=%s is not supported (allowed: DAILY, WEEKLY, MONTHLY)", value)
+			}
+		case "INTERVAL":
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 || n > MaxRecurrenceInterval {
+				return nil, fmt.Errorf("INTERVAL must be a number from 1 to %d", MaxRecurrenceInterval)
+			}
+			r.Interval = n
+		case "COUNT":
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 {
+				return nil, errors.New("COUNT must be a positive number")
+			}
+			r.Count = n
+		case "UNTIL":
+			until, err := parseUntil(value)
+			if err != nil {
+				return nil, err
+			}
+			r.Until = &until
+		case "BYDAY":
+			for _, code := range strings.Split(value, ",") {
+				day, err := parseWeekdayNum(code)
+				if err != nil {
+					return nil, err
+				}
+				if !slices.Contains(r.ByDay, day) {
+					r.ByDay = append(r.ByDay, day)
+				}
+			}
+		case "WKST":
+			// Недели начинаются с понедельника; другое начало недели не поддерживается
+			if value != "MO" {
+				return nil, errors.New("only WKST=MO is supported")
+			}
+		default:
+			return nil, fmt.Errorf("%s is not supported", name)
+		}
+	}
+
+	if r.Freq == "" {
+		return nil, errors.New("FREQ is required")
+	}
+	if r.Count > 0 && r.Until != nil {
+		return nil, errors.New("COUNT and UNTIL cannot be used together")
+	}
+	if r.Freq != FreqMonthly && slices.ContainsFunc(r.ByDay, func(d WeekdayNum) bool { return d.N != 0 }) {
+		return nil, fmt.Errorf("numbered BYDAY (like 2MO) is allowed only with FREQ=MONTHLY")
+	}
+
+	return r, nil
+}
+
+// parseUntil - UNTIL: дата-время в UTC или дата
+func parseUntil(value string) (time.Time, error) {
+	if t, err := time.Parse(untilLayout, value); err == nil {
+		return t, nil
+	}
+
+	if t, err := time.Parse("20060102", value); err == nil {
+		return t.Add(24*time.Hour - time.Second), nil
+	}
+
+	return time.Time{}, fmt.Errorf("UNTIL must be YYYYMMDD or YYYYMMDDTHHMMSSZ, got %q", value)
+}
+
+// parseWeekdayNum - "MO", "2TU", "-1FR"
+func parseWeekdayNum(code string) (WeekdayNum, error) {
+	if len(code) < 2 {
+		return WeekdayNum{}, fmt.Errorf("invalid BYDAY value %q", code)
+	}
+
+	day, ok := weekdayCodes[code[len(code)-2:]]
+	if !ok {
+		return WeekdayNum{}, fmt.Errorf("invalid BYDAY value %q (days: MO, TU, WE, TH, FR, SA, SU)", code)
+	}
+
+	var n int
+	if prefix := code[:len(code)-2]; prefix != "" {
+		var err error
+		n, err = strconv.Atoi(prefix)
+		if err != nil || n == 0 || n < -5 || n > 5 {
+			return WeekdayNum{}, fmt.Errorf("invalid BYDAY value %q (number must be from -5 to 5, not 0)", code)
+		}
+	}
+
+	return WeekdayNum{N: n, Day: day}, nil
+}
+
+// String - правило в каноническом виде RRULE (без префикса "RRULE:")
+func (r Recurrence) String() string {
+	parts := []string{"FREQ=" + string(r.Freq)}
+
+	if r.Interval > 1 {
+		parts = append(parts, "INTERVAL="+strconv.Itoa(r.Interval))
+	}
+
+	if len(r.ByDay) > 0 {
+		days := make([]string, 0, len(r.ByDay))
+		for _, d := range r.ByDay {
+			days = append(days, d.String())
+		}
+		parts = append(parts, "BYDAY="+strings.Join(days, ","))
+	}
+
+	if r.Count > 0 {
+		parts = append(parts, "COUNT="+strconv.Itoa(r.Count))
+	}
+
+	if r.Until != nil {
+		parts = append(parts, "UNTIL="+r.Until.UTC().Format(untilLayout))
+	}
+
+	return strings.Join(parts, ";")
+}
+
+// String - элемент BYDAY в формате RRULE
+func (d WeekdayNum) String() string {
+	code := strings.ToUpper(d.Day.String()[:2])
+	if d.N != 0 {
+		return strconv.Itoa(d.N) + code
+	}
+	return code
+}
+
+// maxRecurrenceSteps - сколько периодов просматривается в поиске следующего повторения
+// Правило вроде "31 число каждые 2 месяца" или "DAILY;INTERVAL=7;BYDAY=MO" от вторника
+// может не дать ни одного повторения - поиск не должен быть бесконечным
+const maxRecurrenceSteps = 1000
+
+// Next - срок следующего повторения после due (срока текущей задачи) и правило для него
+// (COUNT уменьшается на одно повторение). ok == false - серия закончилась:
+// исчерпан COUNT, следующий срок позже UNTIL или правило больше не дает дат
+func (r Recurrence) Next(due time.Time) (next time.Time, rule *Recurrence, ok bool) {
+	if r.Count == 1 {
+		return time.Time{}, nil, false
+	}
+
+	due = due.UTC()
+	interval := max(r.Interval, 1)
+
+	switch r.Freq {
+	case FreqDaily:
+		next, ok = r.nextDaily(due, interval)
+	case FreqWeekly:
+		next, ok = r.nextWeekly(due, interval)
+	case FreqMonthly:
+		next, ok = r.nextMonthly(due, interval)
+	}
+
+	if !ok || (r.Until != nil && next.After(*r.Until)) {
+		return time.Time{}, nil, false
+	}
+
+	following := r
+	if following.Count > 0 {
+		following.Count--
+	}
+
+	return next, &following, true
+}
+
+// nextDaily - через interval дней; BYDAY оставляет только указанные дни недели
+func (r Recurrence) nextDaily(due time.Time, interval int) (time.Time, bool) {
+	for step := 1; step <= maxRecurrenceSteps; step++ {
+		t := due.AddDate(0, 0, step*interval)
+		if len(r.ByDay) == 0 || r.hasWeekday(t.Weekday()) {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// nextWeekly - следующий день из BYDAY в этой неделе, иначе первый из них через interval недель
+// Без BYDAY - тот же день недели, что у due
+func (r Recurrence) nextWeekly(due time.Time, interval int) (time.Time, bool) {
+	if len(r.ByDay) == 0 {
+		return due.AddDate(0, 0, 7*interval), true
+	}
+
+	// Смещения дней от понедельника по возрастанию
+	offsets := make([]int, 0, len(r.ByDay))
+	for _, d := range r.ByDay {
+		offsets = append(offsets, mondayOffset(d.Day))
+	}
+	slices.Sort(offsets)
+
+	current := mondayOffset(due.Weekday())
+	for _, off := range offsets {
+		if off > current {
+			return due.AddDate(0, 0, off-current), true
+		}
+	}
+
+	return due.AddDate(0, 0, 7*interval-current+offsets[0]), true
+}
+
+// nextMonthly - то же число через interval месяцев (месяцы без этого числа пропускаются)
+// или ближайший подходящий под BYDAY день в этом месяце и далее через interval месяцев
+func (r Recurrence) nextMonthly(due time.Time, interval int) (time.Time, bool) {
+	year, month, day := due.Date()
+	hour, minute, sec := due.Clock()
+
+	for step := 0; step <= maxRecurrenceSteps; step++ {
+		// Первое число нужного месяца: AddDate от due переполнил бы 31 число в следующий месяц
+		first := time.Date(year, month+time.Month(step*interval), 1, hour, minute, sec, due.Nanosecond(), time.UTC)
+
+		if len(r.ByDay) == 0 {
+			if step == 0 {
+				continue
+			}
+			t := first.AddDate(0, 0, day-1)
+			if t.Month() == first.Month() {
+				return t, true
+			}
+			continue
+		}
+
+		for _, t := range r.monthDays(first) {
+			if t.After(due) {
+				return t, true
+			}
+		}
+	}
+
+	return time.Time{}, false
+}
+
+// monthDays - дни месяца, начинающегося с first, подходящие под BYDAY, по возрастанию
+func (r Recurrence) monthDays(first time.Time) []time.Time {
+	last := first.AddDate(0, 1, -1).Day()
+
+	var days []time.Time
+	for dayOfMonth := 1; dayOfMonth <= last; dayOfMonth++ {
+		t := first.AddDate(0, 0, dayOfMonth-1)
+		nth := (dayOfMonth-1)/7 + 1              // какой по счету такой день недели в месяце
+		nthFromEnd := -((last-dayOfMonth)/7 + 1) // какой с конца
+
+		for _, d := range r.ByDay {
+			if d.Day == t.Weekday() && (d.N == 0 || d.N == nth || d.N == nthFromEnd) {
+				days = append(days, t)
+				break
+			}
+		}
+	}
+
+	return days
+}
+
+// hasWeekday - день недели есть в BYDAY
+func (r Recurrence) hasWeekday(day time.Weekday) bool {
+	return slices.ContainsFunc(r.ByDay, func(d WeekdayNum) bool { return d.Day == day })
+}
+
+// mondayOffset - номер дня в неделе, начинающейся с понедельника (WKST=MO): 0..6
+func mondayOffset(day time.Weekday) int {
+	return (int(day) + 6) % 7
+}
+
+// Value - правило хранится в колонке todos.recurrence строкой RRULE (driver.Valuer)
+func (r Recurrence) Value() (driver.Value, error) {
+	return r.String(), nil
+}
+
+// Scan - читает колонку todos.recurrence (sql.Scanner); NULL дает nil *Recurrence
+func (r *Recurrence) Scan(src any) error {
+	var rule string
+	switch v := src.(type) {
+	case string:
+		rule = v
+	case []byte:
+		rule = string(v)
+	default:
+		return fmt.Errorf("recurrence: cannot scan %T", src)
+	}
+
+	parsed, err := ParseRecurrence(rule)
+	if err != nil {
+		return fmt.Errorf("recurrence %q: %w", rule, err)
+	}
+
+	*r = *parsed
+	return nil
+}
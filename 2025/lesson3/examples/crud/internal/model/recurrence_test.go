@@ -0,0 +1,113 @@
+package model
+
+import (
+	"testing"
+	"time"
+)
+
+// TestRecurrenceNext - последовательность сроков для разных правил
+func TestRecurrenceNext(t *testing.T) {
+	day := func(s string) time.Time {
+		t, err := time.Parse("2006-01-02 15:04", s)
+		if err != nil {
+			panic(err)
+		}
+		return t
+	}
+
+	tests := []struct {
+		rule  string
+		start string
+		want  []string // следующие сроки; серия заканчивается после последнего
+	}{
+		{
+			rule:  "FREQ=DAILY;INTERVAL=3;COUNT=4",
+			start: "2025-01-30 09:00",
+			want:  []string{"2025-02-02 09:00", "2025-02-05 09:00", "2025-02-08 09:00"},
+		},
+		{
+			rule:  "FREQ=DAILY;BYDAY=MO,TU,WE,TH,FR;COUNT=3",
+			start: "2025-01-03 18:00", // пятница
+			want:  []string{"2025-01-06 18:00", "2025-01-07 18:00"},
+		},
+		{
+			rule:  "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,TH;UNTIL=20250127",
+			start: "2025-01-01 10:00", // среда
+			want:  []string{"2025-01-02 10:00", "2025-01-13 10:00", "2025-01-16 10:00", "2025-01-27 10:00"},
+		},
+		{
+			rule:  "RRULE:FREQ=WEEKLY;COUNT=2",
+			start: "2025-01-01 10:00",
+			want:  []string{"2025-01-08 10:00"},
+		},
+		{
+			rule:  "FREQ=MONTHLY;COUNT=4",
+			start: "2025-01-31 12:00", // в феврале и апреле нет 31 числа
+			want:  []string{"2025-03-31 12:00", "2025-05-31 12:00", "2025-07-31 12:00"},
+		},
+		{
+			rule:  "FREQ=MONTHLY;INTERVAL=2;BYDAY=-1FR;COUNT=3",
+			start: "2025-01-31 08:00", // последняя пятница января
+			want:  []string{"2025-03-28 08:00", "2025-05-30 08:00"},
+		},
+		{
+			rule:  "FREQ=MONTHLY;BYDAY=1MO,3MO;COUNT=4",
+			start: "2025-01-06 08:00",
+			want:  []string{"2025-01-20 08:00", "2025-02-03 08:00", "2025-02-17 08:00"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.rule, func(t *testing.T) {
+			rule, err := ParseRecurrence(tt.rule)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			due := day(tt.start)
+			for i, want := range tt.want {
+				next, following, ok := rule.Next(due)
+				if !ok {
+					t.Fatalf("occurrence %d: series ended, want %s", i+1, want)
+				}
+				if !next.Equal(day(want)) {
+					t.Fatalf("occurrence %d = %s, want %s", i+1, next.Format("2006-01-02 15:04 Mon"), want)
+				}
+				due, rule = next, following
+			}
+
+			if next, _, ok := rule.Next(due); ok {
+				t.Errorf("series must end, got %s", next)
+			}
+		})
+	}
+}
+
+// TestParseRecurrence - канонический вид правила и ошибки разбора
+func TestParseRecurrence(t *testing.T) {
+	rule, err := ParseRecurrence("rrule:freq=weekly;byday=we,mo;interval=1;until=20250301T120000Z")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := rule.String(), "FREQ=WEEKLY;BYDAY=WE,MO;UNTIL=20250301T120000Z"; got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+
+	for _, bad := range []string{
+		"",
+		"FREQ=YEARLY",
+		"INTERVAL=2",
+		"FREQ=DAILY;INTERVAL=0",
+		"FREQ=DAILY;COUNT=2;UNTIL=20250101",
+		"FREQ=WEEKLY;BYDAY=2MO",
+		"FREQ=MONTHLY;BYDAY=6MO",
+		"FREQ=MONTHLY;BYDAY=XX",
+		"FREQ=MONTHLY;BYMONTHDAY=15",
+		"FREQ=DAILY;FREQ=WEEKLY",
+		"FREQ=DAILY;UNTIL=tomorrow",
+	} {
+		if _, err := ParseRecurrence(bad); err == nil {
+			t.Errorf("ParseRecurrence(%q): expected error", bad)
+		}
+	}
+}
@@ -0,0 +1,17 @@
+package model
+
+// Маркеры начала и конца подсветки в TodoSearchHit.*Snippet
+// Символы из Private Use Area не встречаются в обычном тексте, поэтому
+// HTTP слой может безопасно экранировать сниппет и заменить маркеры на <mark>
+const (
+	HighlightStart = "\uE000"
+	HighlightStop  = "\uE001"
+)
+
+// TodoSearchHit - задача, найденная полнотекстовым поиском
+type TodoSearchHit struct {
+	Todo               *Todo
+	Rank               float64 // релевантность: чем больше, тем выше в выдаче
+	TitleSnippet       string  // заголовок с подсвеченными совпадениями
+	DescriptionSnippet string  // фрагменты описания с подсвеченными совпадениями
+}
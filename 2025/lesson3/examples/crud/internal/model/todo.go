@@ -5,11 +5,36 @@ import "time"
 // Todo - модель задачи (Entity)
 // Теги `db` используются библиотекой sqlx для автоматического маппинга
 type Todo struct {
-	ID          int64     `db:"id"`
-	UserID      int64     `db:"user_id"`
-	Title       string    `db:"title"`
-	Description string    `db:"description"`
-	Completed   bool      `db:"completed"`
-	CreatedAt   time.Time `db:"created_at"`
-	UpdatedAt   time.Time `db:"updated_at"`
+	ID          int64       `db:"id"`
+	UserID      int64       `db:"user_id"`
+	Title       string      `db:"title"`
+	Description string      `db:"description"`
+	Completed   bool        `db:"completed"`
+	DueAt       *time.Time  `db:"due_at"` // срок выполнения (UTC), nil - без срока
+	Priority    Priority    `db:"priority"`
+	Recurrence  *Recurrence `db:"recurrence"` // правило повторения (RRULE), nil - задача не повторяется
+	ParentID    *int64      `db:"parent_id"`  // родительская задача, nil - задача верхнего уровня
+	Version     int64       `db:"version"`    // увеличивается при каждом изменении (оптимистичная блокировка)
+	CreatedAt   time.Time   `db:"created_at"`
+	UpdatedAt   time.Time   `db:"updated_at"`
+	DeletedAt   *time.Time  `db:"deleted_at"` // nil - задача не удалена; иначе лежит в корзине
+	Tags        []string    `db:"-"`          // хранятся в таблицах tags/todo_tags, загружаются отдельным запросом
+	BlockedBy   []int64     `db:"-"`          // ID задач, которые нужно выполнить раньше (todo_dependencies), по возрастанию
+}
+
+// TodoPatch - частичное обновление задачи
+// nil означает "поле не меняется"
+type TodoPatch struct {
+	Title           *string
+	Description     *string
+	Completed       *bool
+	DueAt           *time.Time
+	ClearDueAt      bool // true - убрать срок выполнения (в JSON "due_at": null)
+	Priority        *Priority
+	Tags            *[]string // пустой slice убирает все теги
+	Recurrence      *Recurrence
+	ClearRecurrence bool // true - задача больше не повторяется (в JSON "recurrence": null)
+	ParentID        *int64
+	ClearParent     bool     // true - задача становится задачей верхнего уровня (в JSON "parent_id": null)
+	BlockedBy       *[]int64 // пустой slice убирает все зависимости
 }
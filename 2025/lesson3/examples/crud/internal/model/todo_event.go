@@ -0,0 +1,80 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// TodoEventType - вид изменения задачи в истории
+type TodoEventType string
+
+// Допустимые значения TodoEventType
+const (
+	TodoCreated   TodoEventType = "created"
+	TodoUpdated   TodoEventType = "updated"
+	TodoCompleted TodoEventType = "completed"
+	TodoDeleted   TodoEventType = "deleted"
+	TodoRestored  TodoEventType = "restored"
+	TodoReverted  TodoEventType = "reverted"
+)
+
+// TodoEvent - запись истории изменений задачи (таблица todo_events)
+// Before и After - JSON объекты только с изменившимися полями TodoSnapshot
+type TodoEvent struct {
+	ID        int64
+	TodoID    int64
+	ActorID   int64 // пользователь, который выполнил изменение
+	Type      TodoEventType
+	Version   int64           // версия задачи после изменения
+	Before    json.RawMessage // nil для created
+	After     json.RawMessage
+	CreatedAt time.Time
+}
+
+// TodoSnapshot - состояние задачи, которое отслеживается в истории
+type TodoSnapshot struct {
+	Title       string     `json:"title"`
+	Description string     `json:"description"`
+	Completed   bool       `json:"completed"`
+	DueAt       *time.Time `json:"due_at"`
+	Priority    string     `json:"priority"`
+	Tags        []string   `json:"tags"`
+	Deleted     bool       `json:"deleted"`
+	Recurrence  string     `json:"recurrence"` // RRULE, "" - не повторяется
+	ParentID    *int64     `json:"parent_id"`
+	BlockedBy   []int64    `json:"blocked_by"`
+}
+
+// NewTodoSnapshot - снимок отслеживаемых полей задачи
+func NewTodoSnapshot(todo *Todo) TodoSnapshot {
+	tags := todo.Tags
+	if tags == nil {
+		tags = []string{}
+	}
+
+	blockedBy := todo.BlockedBy
+	if blockedBy == nil {
+		blockedBy = []int64{}
+	}
+
+	return TodoSnapshot{
+		Title:       todo.Title,
+		Description: todo.Description,
+		Completed:   todo.Completed,
+		DueAt:       todo.DueAt,
+		Priority:    todo.Priority.String(),
+		Tags:        tags,
+		Deleted:     todo.DeletedAt != nil,
+		Recurrence:  recurrenceString(todo.Recurrence),
+		ParentID:    todo.ParentID,
+		BlockedBy:   blockedBy,
+	}
+}
+
+// recurrenceString - RRULE или "", если правила нет
+func recurrenceString(r *Recurrence) string {
+	if r == nil {
+		return ""
+	}
+	return r.String()
+}
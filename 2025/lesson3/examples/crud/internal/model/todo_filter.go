@@ -0,0 +1,71 @@
+package model
+
+import "time"
+
+// TodoSort - порядок сортировки списка задач
+type TodoSort string
+
+// Допустимые значения TodoSort
+const (
+	SortCreatedAt TodoSort = "created_at" // новые первыми: created_at DESC, id DESC (по умолчанию)
+	SortDueAt     TodoSort = "due_at"     // ближайший срок первым: due_at ASC NULLS LAST, id ASC
+	SortPriority  TodoSort = "priority"   // важные первыми: priority DESC, id DESC
+)
+
+// Valid - значение входит в перечисление
+func (s TodoSort) Valid() bool {
+	switch s {
+	case SortCreatedAt, SortDueAt, SortPriority:
+		return true
+	}
+	return false
+}
+
+// TodoFilter - параметры выборки списка задач
+// Нулевые значения полей означают "фильтр не задан"
+type TodoFilter struct {
+	Completed     *bool       // nil - любые задачи
+	TitleContains string      // подстрока в заголовке (без учета регистра)
+	CreatedFrom   time.Time   // created_at >= CreatedFrom
+	CreatedTo     time.Time   // created_at < CreatedTo
+	DueFrom       time.Time   // due_at >= DueFrom (задачи без срока не попадают)
+	DueTo         time.Time   // due_at < DueTo (задачи без срока не попадают)
+	Priority      *Priority   // nil - любой приоритет
+	Tag           string      // задачи с этим тегом
+	Sort          TodoSort    // "" - SortCreatedAt
+	After         *TodoCursor // вернуть задачи после этой позиции (keyset pagination)
+	Limit         int         // максимальное количество задач, 0 - без ограничения
+	Deleted       bool        // true - только задачи в корзине, false - только не удаленные
+}
+
+// TodoCursor - позиция в списке задач
+// Для каждой сортировки заполнены ключи, по которым она идет: ID всегда,
+// плюс CreatedAt, DueAt или Priority
+type TodoCursor struct {
+	Sort      TodoSort   `json:"sort,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+	DueAt     *time.Time `json:"due_at,omitempty"`
+	Priority  Priority   `json:"priority,omitempty"`
+	ID        int64      `json:"id"`
+}
+
+// NewTodoCursor - позиция сразу после todo в списке с сортировкой sort
+func NewTodoCursor(todo *Todo, sort TodoSort) *TodoCursor {
+	if sort == "" {
+		sort = SortCreatedAt
+	}
+
+	return &TodoCursor{
+		Sort:      sort,
+		CreatedAt: todo.CreatedAt,
+		DueAt:     todo.DueAt,
+		Priority:  todo.Priority,
+		ID:        todo.ID,
+	}
+}
+
+// TodoPage - одна страница списка задач
+type TodoPage struct {
+	Todos      []*Todo
+	NextCursor *TodoCursor // nil - больше страниц нет
+}
@@ -0,0 +1,54 @@
+package model
+
+// TodoTree - задача с подзадачами всех уровней (GET /todos/{id}/tree)
+type TodoTree struct {
+	Todo     *Todo
+	Progress int // процент выполнения от 0 до 100, см. NewTodoTree
+	Subtasks []*TodoTree
+}
+
+// NewTodoTree - дерево задачи root из ее потомков descendants; подзадачи идут в порядке descendants
+// Потомок, родителя которого нет среди root и descendants (родитель лежит в корзине), пропускается.
+// Процент выполнения считается снизу вверх: выполненная задача - 100, невыполненная без
+// подзадач - 0, остальные - среднее по подзадачам с округлением вниз
+func NewTodoTree(root *Todo, descendants []*Todo) *TodoTree {
+	tree := &TodoTree{Todo: root}
+
+	nodes := make(map[int64]*TodoTree, len(descendants)+1)
+	nodes[root.ID] = tree
+	for _, todo := range descendants {
+		if todo.ID != root.ID {
+			nodes[todo.ID] = &TodoTree{Todo: todo}
+		}
+	}
+
+	for _, todo := range descendants {
+		if todo.ID == root.ID || todo.ParentID == nil {
+			continue
+		}
+		if parent, ok := nodes[*todo.ParentID]; ok {
+			parent.Subtasks = append(parent.Subtasks, nodes[todo.ID])
+		}
+	}
+
+	tree.computeProgress()
+	return tree
+}
+
+// computeProgress - заполняет Progress у узла и всех его подзадач
+func (t *TodoTree) computeProgress() {
+	sum := 0
+	for _, subtask := range t.Subtasks {
+		subtask.computeProgress()
+		sum += subtask.Progress
+	}
+
+	switch {
+	case t.Todo.Completed:
+		t.Progress = 100
+	case len(t.Subtasks) == 0:
+		t.Progress = 0
+	default:
+		t.Progress = sum / len(t.Subtasks)
+	}
+}
@@ -0,0 +1,47 @@
+package model
+
+import "testing"
+
+// TestNewTodoTree - сборка дерева и процент выполнения по уровням
+func TestNewTodoTree(t *testing.T) {
+	parent := func(id int64) *int64 { return &id }
+
+	root := &Todo{ID: 1}
+	descendants := []*Todo{
+		{ID: 2, ParentID: parent(1), Completed: true},
+		{ID: 3, ParentID: parent(1)},
+		{ID: 4, ParentID: parent(3), Completed: true},
+		{ID: 5, ParentID: parent(3)},
+		{ID: 6, ParentID: parent(3)},
+		{ID: 7, ParentID: parent(1)},
+		{ID: 8, ParentID: parent(99)}, // родитель в корзине
+	}
+
+	tree := NewTodoTree(root, descendants)
+
+	// 2 - 100, 3 - (100+0+0)/3 = 33, 7 - 0
+	if tree.Progress != 44 {
+		t.Errorf("root Progress = %d, want 44", tree.Progress)
+	}
+	if len(tree.Subtasks) != 3 {
+		t.Fatalf("root has %d subtasks, want 3", len(tree.Subtasks))
+	}
+
+	for i, want := range []struct {
+		id       int64
+		progress int
+		subtasks int
+	}{{2, 100, 0}, {3, 33, 3}, {7, 0, 0}} {
+		got := tree.Subtasks[i]
+		if got.Todo.ID != want.id || got.Progress != want.progress || len(got.Subtasks) != want.subtasks {
+			t.Errorf("subtask %d = id %d, progress %d, %d subtasks; want %+v",
+				i, got.Todo.ID, got.Progress, len(got.Subtasks), want)
+		}
+	}
+
+	// Выполненная задача - 100%, даже если подзадачи не выполнены
+	root.Completed = true
+	if tree := NewTodoTree(root, descendants); tree.Progress != 100 {
+		t.Errorf("completed root Progress = %d, want 100", tree.Progress)
+	}
+}
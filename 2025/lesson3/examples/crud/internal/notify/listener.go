@@ -0,0 +1,218 @@
+// Package notify - доставка изменений задач на все реплики через LISTEN/NOTIFY PostgreSQL
+//
+// Триггер todo_events_notify (миграция 0008) отправляет каждую запись истории в канал
+// todo_events после COMMIT. Listener держит отдельное соединение pgx, слушает канал и
+// публикует события в локальный broker.Broker: так GET /todos/stream на любой реплике
+// получает изменения, сделанные через любую другую
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"crud-example/internal/logger"
+	"crud-example/internal/model"
+)
+
+// Channel - канал pg_notify, в который пишет триггер todo_events_notify
+const Channel = "todo_events"
+
+// Задержки между попытками переподключения: удваиваются после каждой неудачи
+const (
+	DefaultMinBackoff = time.Second
+	DefaultMaxBackoff = 30 * time.Second
+)
+
+// pingInterval - если уведомлений нет так долго, соединение проверяется Ping:
+// иначе оборванное без FIN соединение ждало бы уведомлений вечно
+const pingInterval = 30 * time.Second
+
+// pingTimeout - сколько ждать ответа на Ping
+const pingTimeout = 5 * time.Second
+
+// eventSink - получатель событий (broker.Broker)
+type eventSink interface {
+	Publish(event *model.TodoEvent)
+	Reset()
+}
+
+// eventGetter - чтение записи истории, которая не поместилась в уведомление
+type eventGetter interface {
+	GetEvent(ctx context.Context, id int64) (*model.TodoEvent, error)
+}
+
+// Listener - слушает канал todo_events и публикует события в sink
+type Listener struct {
+	dsn        string
+	events     eventGetter
+	sink       eventSink
+	minBackoff time.Duration
+	maxBackoff time.Duration
+}
+
+// NewListener - создает слушателя; dsn - строка подключения к той же базе, что у пула
+func NewListener(dsn string, events eventGetter, sink eventSink) *Listener {
+	return &Listener{
+		dsn:        dsn,
+		events:     events,
+		sink:       sink,
+		minBackoff: DefaultMinBackoff,
+		maxBackoff: DefaultMaxBackoff,
+	}
+}
+
+// Run - слушает канал, пока не отменен ctx; после обрыва переподключается с растущей задержкой
+// Уведомления, отправленные пока соединения не было, PostgreSQL не хранит, поэтому после
+// переподключения брокер сбрасывается: клиенты потока получат reset и перечитают задачи.
+// Ошибки пишутся в логгер из ctx (logger.FromContext)
+func (l *Listener) Run(ctx context.Context) {
+	log := logger.FromContext(ctx)
+	backoff := l.minBackoff
+	listened := false
+
+	for {
+		err := l.listen(ctx, func() {
+			if listened {
+				l.sink.Reset()
+			}
+			listened = true
+			backoff = l.minBackoff
+			log.Info("listening for todo events", "channel", Channel)
+		})
+		if ctx.Err() != nil {
+			return
+		}
+
+		// Случайная добавка, чтобы реплики после перезапуска базы не подключались одновременно
+		delay := backoff + rand.N(backoff/2+1)
+		log.Warn("todo events listener disconnected", "error", err, "retry_in", delay.String())
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+
+		backoff = min(backoff*2, l.maxBackoff)
+	}
+}
+
+// listen - одно подключение: LISTEN и чтение уведомлений до первой ошибки
+// onListen вызывается, когда подписка на канал оформлена
+func (l *Listener) listen(ctx context.Context, onListen func()) error {
+	conn, err := pgx.Connect(ctx, l.dsn)
+	if err != nil {
+		return fmt.Errorf("connect: %w", err)
+	}
+	defer conn.Close(context.WithoutCancel(ctx))
+
+	if _, err := conn.Exec(ctx, "LISTEN "+Channel); err != nil {
+		return fmt.Errorf("listen %s: %w", Channel, err)
+	}
+	onListen()
+
+	for {
+		waitCtx, cancel := context.WithTimeout(ctx, pingInterval)
+		n, err := conn.WaitForNotification(waitCtx)
+		cancel()
+
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			if !errors.Is(waitCtx.Err(), context.DeadlineExceeded) {
+				return fmt.Errorf("wait for notification: %w", err)
+			}
+
+			// Уведомлений не было pingInterval - проверяем, что соединение живо
+			pingCtx, cancel := context.WithTimeout(ctx, pingTimeout)
+			err = conn.Ping(pingCtx)
+			cancel()
+			if err != nil {
+				return fmt.Errorf("ping: %w", err)
+			}
+			continue
+		}
+
+		l.handle(ctx, n.Payload)
+	}
+}
+
+// handle - разбирает уведомление и публикует событие
+func (l *Listener) handle(ctx context.Context, payload string) {
+	event, complete, err := decodeEvent(payload)
+	if err == nil && !complete {
+		// Событие было больше лимита pg_notify и пришло только с ID
+		event, err = l.events.GetEvent(ctx, event.ID)
+	}
+
+	if err != nil {
+		// Пропуск события незаметен клиентам, поэтому лучше заставить их перечитать задачи
+		logger.FromContext(ctx).Error("cannot handle todo event notification", "error", err)
+		l.sink.Reset()
+		return
+	}
+
+	l.sink.Publish(event)
+}
+
+// notification - payload уведомления: строка todo_events в виде json_build_object
+type notification struct {
+	ID        int64           `json:"id"`
+	TodoID    int64           `json:"todo_id"`
+	ActorID   int64           `json:"actor_id"`
+	Type      string          `json:"type"`
+	Version   int64           `json:"version"`
+	Before    json.RawMessage `json:"before"`
+	After     json.RawMessage `json:"after"`
+	CreatedAt string          `json:"created_at"`
+}
+
+// timestampLayout - так json_build_object пишет TIMESTAMP (без часового пояса, время в UTC)
+const timestampLayout = "2006-01-02T15:04:05.999999"
+
+// decodeEvent - событие из payload уведомления
+// complete == false, если триггер прислал только ID: событие нужно прочитать из базы
+func decodeEvent(payload string) (event *model.TodoEvent, complete bool, err error) {
+	var n notification
+	if err := json.Unmarshal([]byte(payload), &n); err != nil {
+		return nil, false, fmt.Errorf("decode notification: %w", err)
+	}
+	if n.ID == 0 {
+		return nil, false, errors.New("decode notification: missing id")
+	}
+
+	event = &model.TodoEvent{
+		ID:      n.ID,
+		TodoID:  n.TodoID,
+		ActorID: n.ActorID,
+		Type:    model.TodoEventType(n.Type),
+		Version: n.Version,
+		Before:  nullableJSON(n.Before),
+		After:   nullableJSON(n.After),
+	}
+	if n.Type == "" {
+		return event, false, nil
+	}
+
+	event.CreatedAt, err = time.Parse(timestampLayout, n.CreatedAt)
+	if err != nil {
+		return nil, false, fmt.Errorf("decode notification %d: created_at: %w", n.ID, err)
+	}
+
+	return event, true, nil
+}
+
+// nullableJSON - nil вместо JSON null, как у событий, прочитанных репозиторием
+func nullableJSON(data json.RawMessage) json.RawMessage {
+	if len(data) == 0 || string(data) == "null" {
+		return nil
+	}
+	return data
+}
@@ -0,0 +1,46 @@
+package notify
+
+import (
+	"testing"
+	"time"
+
+	"crud-example/internal/model"
+)
+
+// TestDecodeEvent - payload триггера todo_events_notify разбирается в model.TodoEvent
+func TestDecodeEvent(t *testing.T) {
+	payload := `{"id" : 42, "todo_id" : 7, "actor_id" : 1, "type" : "completed", "version" : 3, ` +
+		`"before" : {"completed": false}, "after" : {"completed": true}, "created_at" : "2025-01-15T10:30:00.123456"}`
+
+	event, complete, err := decodeEvent(payload)
+	if err != nil || !complete {
+		t.Fatalf("decodeEvent = %v, %v", complete, err)
+	}
+
+	want := time.Date(2025, 1, 15, 10, 30, 0, 123456000, time.UTC)
+	if event.ID != 42 || event.TodoID != 7 || event.ActorID != 1 || event.Type != model.TodoCompleted ||
+		event.Version != 3 || !event.CreatedAt.Equal(want) {
+		t.Errorf("event = %+v", event)
+	}
+	if string(event.Before) != `{"completed": false}` || string(event.After) != `{"completed": true}` {
+		t.Errorf("diff = %s -> %s", event.Before, event.After)
+	}
+
+	// created: before = null
+	event, _, err = decodeEvent(`{"id":1,"actor_id":1,"type":"created","before":null,"after":{},"created_at":"2025-01-15T10:30:00"}`)
+	if err != nil || event.Before != nil {
+		t.Errorf("Before = %s, %v; want nil", event.Before, err)
+	}
+
+	// Большое событие: только ID, остальное читается из базы
+	event, complete, err = decodeEvent(`{"id" : 43, "actor_id" : 1}`)
+	if err != nil || complete || event.ID != 43 {
+		t.Errorf("decodeEvent(id only) = %+v, %v, %v", event, complete, err)
+	}
+
+	for _, bad := range []string{`not json`, `{"type":"created"}`, `{"id":1,"type":"created","created_at":"yesterday"}`} {
+		if _, _, err := decodeEvent(bad); err == nil {
+			t.Errorf("decodeEvent(%s): expected error", bad)
+		}
+	}
+}
@@ -0,0 +1,153 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+
+	"crud-example/internal/model"
+)
+
+// ListSubtree - потомки задачи всех уровней (без нее самой) в порядке создания
+// Удаленные задачи не возвращаются, но обход идет и через них: потомков удаленной
+// подзадачи отбрасывает уже model.NewTodoTree. UNION (а не UNION ALL) останавливает
+// обход, даже если в данных оказался цикл
+func (r *PostgresTodoRepository) ListSubtree(ctx context.Context, id int64) ([]*model.Todo, error) {
+	query := `
+		WITH RECURSIVE subtree AS (
+			SELECT id FROM todos WHERE parent_id = $1
+			UNION
+			SELECT t.id FROM todos t JOIN subtree s ON t.parent_id = s.id
+		)
+		SELECT id, user_id, title, description, completed, due_at, priority, recurrence, parent_id, version, created_at, updated_at, deleted_at
+		FROM todos
+		WHERE id IN (SELECT id FROM subtree) AND id <> $1 AND deleted_at IS NULL
+		ORDER BY created_at, id
+	`
+
+	var todos []*model.Todo
+	if err := r.db.SelectContext(ctx, &todos, query, id); err != nil {
+		return nil, fmt.Errorf("list subtree of todo %d: %w", id, err)
+	}
+
+	if err := r.loadRelations(ctx, todos...); err != nil {
+		return nil, fmt.Errorf("list subtree of todo %d: %w", id, err)
+	}
+
+	return todos, nil
+}
+
+// ListDependencies - граф зависимостей задач пользователя: ID задачи → ID блокирующих задач
+// Задачи из корзины тоже входят: после восстановления их зависимости снова действуют
+func (r *PostgresTodoRepository) ListDependencies(ctx context.Context, userID int64) (map[int64][]int64, error) {
+	query := `
+		SELECT d.todo_id, d.blocked_by_id
+		FROM todo_dependencies d
+		JOIN todos t ON t.id = d.todo_id
+		WHERE t.user_id = $1
+		ORDER BY d.todo_id, d.blocked_by_id
+	`
+
+	var rows []struct {
+		TodoID      int64 `db:"todo_id"`
+		BlockedByID int64 `db:"blocked_by_id"`
+	}
+	if err := r.db.SelectContext(ctx, &rows, query, userID); err != nil {
+		return nil, fmt.Errorf("list dependencies of user %d: %w", userID, err)
+	}
+
+	graph := make(map[int64][]int64)
+	for _, row := range rows {
+		graph[row.TodoID] = append(graph[row.TodoID], row.BlockedByID)
+	}
+
+	return graph, nil
+}
+
+// dependencyLockClass - первый ключ pg_advisory_xact_lock(class, user_id) для LockDependencies
+// Двухключевые блокировки не пересекаются с одноключевой блокировкой миграций
+const dependencyLockClass int32 = 25
+
+// LockDependencies - блокирует подзадачи и зависимости пользователя до конца транзакции
+// Проверка циклов читает граф целиком, а потом пишет в него новое ребро: без блокировки две
+// транзакции ("A ждет B" и "B ждет A") обе не нашли бы цикла и обе зафиксировались.
+// Вне транзакции блокировка снимается сразу после запроса
+func (r *PostgresTodoRepository) LockDependencies(ctx context.Context, userID int64) error {
+	if _, err := r.db.ExecContext(ctx, `SELECT pg_advisory_xact_lock($1, $2)`, dependencyLockClass, userID); err != nil {
+		return fmt.Errorf("lock dependencies of user %d: %w", userID, err)
+	}
+	return nil
+}
+
+// setBlockers - заменяет зависимости задачи на todo.BlockedBy
+func (r *PostgresTodoRepository) setBlockers(ctx context.Context, todo *model.Todo) error {
+	blockedBy := todo.BlockedBy
+	if blockedBy == nil {
+		blockedBy = []int64{}
+	}
+
+	query := `
+		WITH removed AS (
+			DELETE FROM todo_dependencies
+			WHERE todo_id = $1 AND blocked_by_id <> ALL($2::integer[])
+		)
+		INSERT INTO todo_dependencies (todo_id, blocked_by_id)
+		SELECT $1, unnest($2::integer[])
+		ON CONFLICT DO NOTHING
+	`
+
+	if _, err := r.db.ExecContext(ctx, query, todo.ID, blockedBy); err != nil {
+		return fmt.Errorf("set blockers: %w", mapError(err))
+	}
+
+	return nil
+}
+
+// loadRelations - заполняет Tags и BlockedBy у задач (по запросу на каждое поле, без N+1)
+func (r *PostgresTodoRepository) loadRelations(ctx context.Context, todos ...*model.Todo) error {
+	if err := r.loadTags(ctx, todos...); err != nil {
+		return err
+	}
+
+	return r.loadBlockers(ctx, todos...)
+}
+
+// loadBlockers - заполняет BlockedBy у задач одним запросом
+func (r *PostgresTodoRepository) loadBlockers(ctx context.Context, todos ...*model.Todo) error {
+	if len(todos) == 0 {
+		return nil
+	}
+
+	byID := make(map[int64]*model.Todo, len(todos))
+	ids := make([]int64, 0, len(todos))
+	for _, todo := range todos {
+		todo.BlockedBy = []int64{}
+		byID[todo.ID] = todo
+		ids = append(ids, todo.ID)
+	}
+
+	query, args, err := sqlx.In(`
+		SELECT todo_id, blocked_by_id
+		FROM todo_dependencies
+		WHERE todo_id IN (?)
+		ORDER BY blocked_by_id
+	`, ids)
+	if err != nil {
+		return fmt.Errorf("load blockers: %w", err)
+	}
+
+	var rows []struct {
+		TodoID      int64 `db:"todo_id"`
+		BlockedByID int64 `db:"blocked_by_id"`
+	}
+	if err := r.db.SelectContext(ctx, &rows, r.db.Rebind(query), args...); err != nil {
+		return fmt.Errorf("load blockers: %w", err)
+	}
+
+	for _, row := range rows {
+		byID[row.TodoID].BlockedBy = append(byID[row.TodoID].BlockedBy, row.BlockedByID)
+	}
+
+	return nil
+}
@@ -0,0 +1,48 @@
+package repository
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgconn"
+
+	"crud-example/internal/model"
+)
+
+func TestMapError(t *testing.T) {
+	tests := []struct {
+		name       string
+		code       string
+		constraint string
+		want       error
+	}{
+		{name: "unique", code: "23505", constraint: "tags_user_id_name_key", want: model.ErrConflict},
+		{name: "check", code: "23514", constraint: "todos_priority_check", want: model.ErrValidation},
+		{name: "missing parent", code: "23503", constraint: "todos_parent_id_fkey", want: model.ErrValidation},
+		{name: "missing blocker", code: "23503", constraint: "todo_dependencies_blocked_by_id_fkey", want: model.ErrValidation},
+		{name: "missing user", code: "23503", constraint: "todos_user_id_fkey", want: model.ErrForbidden},
+		{name: "missing actor", code: "23503", constraint: "todo_events_actor_id_fkey", want: model.ErrForbidden},
+		{name: "todo deleted concurrently", code: "23503", constraint: "todo_tags_todo_id_fkey", want: model.ErrTodoNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pgErr := &pgconn.PgError{Code: tt.code, ConstraintName: tt.constraint}
+
+			err := mapError(pgErr)
+			if !errors.Is(err, tt.want) {
+				t.Errorf("mapError(%s %s) = %v, want %v", tt.code, tt.constraint, err, tt.want)
+			}
+			if !errors.Is(err, pgErr) {
+				t.Errorf("mapError dropped the original error: %v", err)
+			}
+			if tt.want != model.ErrValidation && errors.Is(err, model.ErrValidation) {
+				t.Errorf("mapError(%s %s) is a validation error", tt.code, tt.constraint)
+			}
+		})
+	}
+
+	if err := errors.New("boom"); mapError(err) != err {
+		t.Error("mapError changed a non-PostgreSQL error")
+	}
+}
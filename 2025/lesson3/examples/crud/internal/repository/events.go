@@ -0,0 +1,121 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"crud-example/internal/model"
+)
+
+// todoEventRow - строка todo_events
+// JSONB читаем как текст: json.RawMessage не реализует sql.Scanner
+type todoEventRow struct {
+	ID        int64          `db:"id"`
+	TodoID    int64          `db:"todo_id"`
+	ActorID   int64          `db:"actor_id"`
+	Type      string         `db:"type"`
+	Version   int64          `db:"version"`
+	Before    sql.NullString `db:"before"`
+	After     sql.NullString `db:"after"`
+	CreatedAt time.Time      `db:"created_at"`
+}
+
+// AddEvent - добавляет запись в историю изменений задачи
+// Вызывайте внутри TxManager.WithinTx вместе с самим изменением
+func (r *PostgresTodoRepository) AddEvent(ctx context.Context, event *model.TodoEvent) error {
+	query := `
+		INSERT INTO todo_events (todo_id, actor_id, type, version, before, after)
+		VALUES ($1, $2, $3, $4, $5::text::jsonb, $6::text::jsonb)
+		RETURNING id, created_at
+	`
+
+	err := r.db.QueryRowContext(
+		ctx,
+		query,
+		event.TodoID,
+		event.ActorID,
+		event.Type,
+		event.Version,
+		nullJSON(event.Before),
+		nullJSON(event.After),
+	).Scan(&event.ID, &event.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("add event for todo %d: %w", event.TodoID, mapError(err))
+	}
+
+	return nil
+}
+
+// ListEvents - история изменений задачи в хронологическом порядке
+func (r *PostgresTodoRepository) ListEvents(ctx context.Context, todoID int64) ([]*model.TodoEvent, error) {
+	query := `
+		SELECT id, todo_id, actor_id, type, version, before::text AS before, after::text AS after, created_at
+		FROM todo_events
+		WHERE todo_id = $1
+		ORDER BY id ASC
+	`
+
+	var rows []todoEventRow
+	if err := r.db.SelectContext(ctx, &rows, query, todoID); err != nil {
+		return nil, fmt.Errorf("list events for todo %d: %w", todoID, err)
+	}
+
+	events := make([]*model.TodoEvent, 0, len(rows))
+	for _, row := range rows {
+		events = append(events, row.toModel())
+	}
+
+	return events, nil
+}
+
+// GetEvent - запись истории по ID
+// Нужна слушателю LISTEN/NOTIFY, когда событие не поместилось в уведомление
+func (r *PostgresTodoRepository) GetEvent(ctx context.Context, id int64) (*model.TodoEvent, error) {
+	query := `
+		SELECT id, todo_id, actor_id, type, version, before::text AS before, after::text AS after, created_at
+		FROM todo_events
+		WHERE id = $1
+	`
+
+	var row todoEventRow
+	if err := r.db.GetContext(ctx, &row, query, id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("get event %d: %w", id, model.ErrNotFound)
+		}
+		return nil, fmt.Errorf("get event %d: %w", id, err)
+	}
+
+	return row.toModel(), nil
+}
+
+// toModel - строка todo_events в model.TodoEvent
+func (row todoEventRow) toModel() *model.TodoEvent {
+	event := &model.TodoEvent{
+		ID:        row.ID,
+		TodoID:    row.TodoID,
+		ActorID:   row.ActorID,
+		Type:      model.TodoEventType(row.Type),
+		Version:   row.Version,
+		CreatedAt: row.CreatedAt,
+	}
+	if row.Before.Valid {
+		event.Before = json.RawMessage(row.Before.String)
+	}
+	if row.After.Valid {
+		event.After = json.RawMessage(row.After.String)
+	}
+
+	return event
+}
+
+// nullJSON - NULL для пустого JSON, иначе текст для приведения к jsonb
+func nullJSON(data json.RawMessage) any {
+	if data == nil {
+		return nil
+	}
+	return string(data)
+}
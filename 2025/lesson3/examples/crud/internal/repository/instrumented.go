@@ -0,0 +1,202 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"crud-example/internal/model"
+)
+
+// QueryObserver - получает длительность каждого вызова репозитория
+// Реализация с Prometheus - metrics.Metrics
+type QueryObserver interface {
+	ObserveQuery(method string, d time.Duration)
+}
+
+// instrumentedRepository - TodoRepository, который замеряет длительность каждого метода
+// Метод репозитория - это один или несколько SQL запросов (например, Create пишет и теги)
+type instrumentedRepository struct {
+	next     TodoRepository
+	observer QueryObserver
+}
+
+// WithQueryMetrics - оборачивает repo: длительность каждого вызова уходит в observer
+// Репозиторий внутри транзакции оборачивает InstrumentTx
+func WithQueryMetrics(repo TodoRepository, observer QueryObserver) TodoRepository {
+	return &instrumentedRepository{next: repo, observer: observer}
+}
+
+func (r *instrumentedRepository) observe(method string, start time.Time) {
+	r.observer.ObserveQuery(method, time.Since(start))
+}
+
+func (r *instrumentedRepository) Create(ctx context.Context, todo *model.Todo) (int64, error) {
+	start := time.Now()
+	res, err := r.next.Create(ctx, todo)
+	r.observe("Create", start)
+	return res, err
+}
+
+func (r *instrumentedRepository) GetByID(ctx context.Context, id int64) (*model.Todo, error) {
+	start := time.Now()
+	res, err := r.next.GetByID(ctx, id)
+	r.observe("GetByID", start)
+	return res, err
+}
+
+func (r *instrumentedRepository) GetByIDForUpdate(ctx context.Context, id int64) (*model.Todo, error) {
+	start := time.Now()
+	res, err := r.next.GetByIDForUpdate(ctx, id)
+	r.observe("GetByIDForUpdate", start)
+	return res, err
+}
+
+func (r *instrumentedRepository) GetAllByUserID(ctx context.Context, userID int64) ([]*model.Todo, error) {
+	start := time.Now()
+	res, err := r.next.GetAllByUserID(ctx, userID)
+	r.observe("GetAllByUserID", start)
+	return res, err
+}
+
+func (r *instrumentedRepository) List(ctx context.Context, userID int64, filter model.TodoFilter) ([]*model.Todo, error) {
+	start := time.Now()
+	res, err := r.next.List(ctx, userID, filter)
+	r.observe("List", start)
+	return res, err
+}
+
+func (r *instrumentedRepository) Search(ctx context.Context, userID int64, query string, limit int) ([]*model.TodoSearchHit, error) {
+	start := time.Now()
+	res, err := r.next.Search(ctx, userID, query, limit)
+	r.observe("Search", start)
+	return res, err
+}
+
+func (r *instrumentedRepository) Update(ctx context.Context, todo *model.Todo) error {
+	start := time.Now()
+	err := r.next.Update(ctx, todo)
+	r.observe("Update", start)
+	return err
+}
+
+func (r *instrumentedRepository) Delete(ctx context.Context, id, version int64) error {
+	start := time.Now()
+	err := r.next.Delete(ctx, id, version)
+	r.observe("Delete", start)
+	return err
+}
+
+func (r *instrumentedRepository) BatchInsert(ctx context.Context, todos []*model.Todo) error {
+	start := time.Now()
+	err := r.next.BatchInsert(ctx, todos)
+	r.observe("BatchInsert", start)
+	return err
+}
+
+func (r *instrumentedRepository) GetByIDsForUpdate(ctx context.Context, ids []int64) ([]*model.Todo, error) {
+	start := time.Now()
+	res, err := r.next.GetByIDsForUpdate(ctx, ids)
+	r.observe("GetByIDsForUpdate", start)
+	return res, err
+}
+
+func (r *instrumentedRepository) GetByIDs(ctx context.Context, ids []int64) ([]*model.Todo, error) {
+	start := time.Now()
+	res, err := r.next.GetByIDs(ctx, ids)
+	r.observe("GetByIDs", start)
+	return res, err
+}
+
+func (r *instrumentedRepository) CompleteByIDs(ctx context.Context, userID int64, ids []int64) (int64, error) {
+	start := time.Now()
+	res, err := r.next.CompleteByIDs(ctx, userID, ids)
+	r.observe("CompleteByIDs", start)
+	return res, err
+}
+
+func (r *instrumentedRepository) DeleteByIDs(ctx context.Context, userID int64, ids []int64) (int64, error) {
+	start := time.Now()
+	res, err := r.next.DeleteByIDs(ctx, userID, ids)
+	r.observe("DeleteByIDs", start)
+	return res, err
+}
+
+func (r *instrumentedRepository) Restore(ctx context.Context, userID, id int64) (*model.Todo, error) {
+	start := time.Now()
+	res, err := r.next.Restore(ctx, userID, id)
+	r.observe("Restore", start)
+	return res, err
+}
+
+func (r *instrumentedRepository) PurgeDeleted(ctx context.Context, olderThan time.Duration) (int64, error) {
+	start := time.Now()
+	res, err := r.next.PurgeDeleted(ctx, olderThan)
+	r.observe("PurgeDeleted", start)
+	return res, err
+}
+
+func (r *instrumentedRepository) AddEvent(ctx context.Context, event *model.TodoEvent) error {
+	start := time.Now()
+	err := r.next.AddEvent(ctx, event)
+	r.observe("AddEvent", start)
+	return err
+}
+
+func (r *instrumentedRepository) ListEvents(ctx context.Context, todoID int64) ([]*model.TodoEvent, error) {
+	start := time.Now()
+	res, err := r.next.ListEvents(ctx, todoID)
+	r.observe("ListEvents", start)
+	return res, err
+}
+
+func (r *instrumentedRepository) GetEvent(ctx context.Context, id int64) (*model.TodoEvent, error) {
+	start := time.Now()
+	res, err := r.next.GetEvent(ctx, id)
+	r.observe("GetEvent", start)
+	return res, err
+}
+
+func (r *instrumentedRepository) ListSubtree(ctx context.Context, id int64) ([]*model.Todo, error) {
+	start := time.Now()
+	res, err := r.next.ListSubtree(ctx, id)
+	r.observe("ListSubtree", start)
+	return res, err
+}
+
+func (r *instrumentedRepository) ListDependencies(ctx context.Context, userID int64) (map[int64][]int64, error) {
+	start := time.Now()
+	res, err := r.next.ListDependencies(ctx, userID)
+	r.observe("ListDependencies", start)
+	return res, err
+}
+
+func (r *instrumentedRepository) LockDependencies(ctx context.Context, userID int64) error {
+	start := time.Now()
+	err := r.next.LockDependencies(ctx, userID)
+	r.observe("LockDependencies", start)
+	return err
+}
+
+// instrumentedTxManager - TxManager, который оборачивает репозиторий транзакции в WithQueryMetrics
+type instrumentedTxManager struct {
+	next     TxManager
+	observer QueryObserver
+}
+
+// InstrumentTx - оборачивает tx: вызовы репозитория внутри WithinTx тоже замеряются
+func InstrumentTx(tx TxManager, observer QueryObserver) TxManager {
+	return &instrumentedTxManager{next: tx, observer: observer}
+}
+
+// WithinTx - вызывает fn с репозиторием транзакции, обернутым в WithQueryMetrics
+func (m *instrumentedTxManager) WithinTx(ctx context.Context, fn func(repo TodoRepository) error) error {
+	return m.next.WithinTx(ctx, func(repo TodoRepository) error {
+		return fn(WithQueryMetrics(repo, m.observer))
+	})
+}
+
+// Проверка на этапе компиляции, что обертки удовлетворяют интерфейсам
+var (
+	_ TodoRepository = (*instrumentedRepository)(nil)
+	_ TxManager      = (*instrumentedTxManager)(nil)
+)
@@ -0,0 +1,600 @@
+package repository
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"crud-example/internal/model"
+)
+
+// InMemoryTodoRepository - реализация TodoRepository в памяти
+// Повторяет поведение PostgresTodoRepository (автоинкремент ID, время создания,
+// версии, сортировку и ошибки), чтобы сервис можно было тестировать без базы
+type InMemoryTodoRepository struct {
+	mu          sync.RWMutex
+	todos       map[int64]*model.Todo
+	events      map[int64][]*model.TodoEvent // история по ID задачи
+	nextID      int64
+	nextEventID int64
+	now         func() time.Time
+}
+
+// Проверка на этапе компиляции, что реализация удовлетворяет интерфейсу
+var _ TodoRepository = (*InMemoryTodoRepository)(nil)
+
+// NewInMemoryTodoRepository - создает пустой репозиторий в памяти
+func NewInMemoryTodoRepository() *InMemoryTodoRepository {
+	return &InMemoryTodoRepository{
+		todos:       make(map[int64]*model.Todo),
+		events:      make(map[int64][]*model.TodoEvent),
+		nextID:      1,
+		nextEventID: 1,
+		now: func() time.Time {
+			// TIMESTAMP в Postgres хранит микросекунды и без часового пояса
+			return time.Now().UTC().Truncate(time.Microsecond)
+		},
+	}
+}
+
+// Create - добавляет задачу, проставляя ID, версию и время как это делает база
+func (r *InMemoryTodoRepository) Create(ctx context.Context, todo *model.Todo) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.insert(todo, r.now())
+	return todo.ID, nil
+}
+
+// GetByID - получает задачу по ID
+func (r *InMemoryTodoRepository) GetByID(ctx context.Context, id int64) (*model.Todo, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	stored, ok := r.todos[id]
+	if !ok || stored.DeletedAt != nil {
+		return nil, fmt.Errorf("get todo %d: %w", id, model.ErrTodoNotFound)
+	}
+
+	// Возвращаем копию: изменения у вызывающего не должны попадать в "базу" без Update
+	return cloneTodo(stored), nil
+}
+
+// GetByIDForUpdate - в памяти блокировать строки не нужно, поведение как у GetByID
+func (r *InMemoryTodoRepository) GetByIDForUpdate(ctx context.Context, id int64) (*model.Todo, error) {
+	return r.GetByID(ctx, id)
+}
+
+// GetAllByUserID - получает все задачи пользователя (новые первыми)
+func (r *InMemoryTodoRepository) GetAllByUserID(ctx context.Context, userID int64) ([]*model.Todo, error) {
+	return r.List(ctx, userID, model.TodoFilter{})
+}
+
+// List - получает задачи пользователя с фильтрами и keyset-пагинацией
+// Filter.Limit == 0 означает "без ограничения"
+func (r *InMemoryTodoRepository) List(ctx context.Context, userID int64, filter model.TodoFilter) ([]*model.Todo, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	title := strings.ToLower(filter.TitleContains)
+
+	var todos []*model.Todo
+	for _, stored := range r.todos {
+		switch {
+		case stored.UserID != userID:
+			continue
+		case filter.Deleted != (stored.DeletedAt != nil):
+			continue
+		case filter.Completed != nil && stored.Completed != *filter.Completed:
+			continue
+		case title != "" && !strings.Contains(strings.ToLower(stored.Title), title):
+			continue
+		case !filter.CreatedFrom.IsZero() && stored.CreatedAt.Before(filter.CreatedFrom):
+			continue
+		case !filter.CreatedTo.IsZero() && !stored.CreatedAt.Before(filter.CreatedTo):
+			continue
+		case !filter.DueFrom.IsZero() && (stored.DueAt == nil || stored.DueAt.Before(filter.DueFrom)):
+			continue
+		case !filter.DueTo.IsZero() && (stored.DueAt == nil || !stored.DueAt.Before(filter.DueTo)):
+			continue
+		case filter.Priority != nil && stored.Priority != *filter.Priority:
+			continue
+		case filter.Tag != "" && !slices.Contains(stored.Tags, filter.Tag):
+			continue
+		case filter.After != nil && !comesAfter(stored, cursorTodo(filter.After), filter.Sort):
+			continue
+		}
+
+		todos = append(todos, cloneTodo(stored))
+	}
+
+	sortTodos(todos, filter.Sort)
+
+	if filter.Limit > 0 && len(todos) > filter.Limit {
+		todos = todos[:filter.Limit]
+	}
+
+	return todos, nil
+}
+
+// Search - упрощенный аналог полнотекстового поиска Postgres для тестов:
+// задача подходит, если каждое слово запроса встречается в заголовке или описании
+// (без учета регистра и без стемминга). Совпадения в заголовке весят больше
+func (r *InMemoryTodoRepository) Search(ctx context.Context, userID int64, query string, limit int) ([]*model.TodoSearchHit, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	words := strings.Fields(strings.ToLower(query))
+	if len(words) == 0 {
+		return nil, nil
+	}
+
+	var hits []*model.TodoSearchHit
+	for _, stored := range r.todos {
+		if stored.UserID != userID || stored.DeletedAt != nil {
+			continue
+		}
+
+		title := strings.ToLower(stored.Title)
+		description := strings.ToLower(stored.Description)
+
+		var rank float64
+		for _, word := range words {
+			inTitle := strings.Count(title, word)
+			inDescription := strings.Count(description, word)
+			if inTitle+inDescription == 0 {
+				rank = 0
+				break
+			}
+			rank += float64(inTitle) + 0.4*float64(inDescription)
+		}
+		if rank == 0 {
+			continue
+		}
+
+		hits = append(hits, &model.TodoSearchHit{
+			Todo:               cloneTodo(stored),
+			Rank:               rank,
+			TitleSnippet:       highlight(stored.Title, words),
+			DescriptionSnippet: highlight(stored.Description, words),
+		})
+	}
+
+	// ORDER BY rank DESC, id DESC
+	slices.SortFunc(hits, func(a, b *model.TodoSearchHit) int {
+		if c := cmp.Compare(b.Rank, a.Rank); c != 0 {
+			return c
+		}
+		return cmp.Compare(b.Todo.ID, a.Todo.ID)
+	})
+
+	if limit > 0 && len(hits) > limit {
+		hits = hits[:limit]
+	}
+
+	return hits, nil
+}
+
+// Update - обновляет задачу, если ее версия совпадает с todo.Version
+func (r *InMemoryTodoRepository) Update(ctx context.Context, todo *model.Todo) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.todos[todo.ID]
+	if !ok || stored.DeletedAt != nil {
+		return fmt.Errorf("update todo %d: %w", todo.ID, model.ErrTodoNotFound)
+	}
+
+	if stored.Version != todo.Version {
+		return fmt.Errorf("update todo %d: %w", todo.ID, model.ErrVersionConflict)
+	}
+
+	stored.Title = todo.Title
+	stored.Description = todo.Description
+	stored.Completed = todo.Completed
+	stored.DueAt = todo.DueAt
+	stored.Priority = todo.Priority
+	stored.Recurrence = todo.Recurrence
+	stored.ParentID = todo.ParentID
+	stored.Tags = sortedTags(todo.Tags)
+	stored.BlockedBy = sortedIDs(todo.BlockedBy)
+	stored.Version++
+	stored.UpdatedAt = r.now()
+
+	todo.Version = stored.Version
+	todo.UpdatedAt = stored.UpdatedAt
+
+	return nil
+}
+
+// Delete - мягко удаляет задачу, если ее версия совпадает с ожидаемой
+func (r *InMemoryTodoRepository) Delete(ctx context.Context, id, version int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.todos[id]
+	if !ok || stored.DeletedAt != nil {
+		return fmt.Errorf("todo %d: %w", id, model.ErrTodoNotFound)
+	}
+
+	if stored.Version != version {
+		return fmt.Errorf("todo %d: %w", id, model.ErrVersionConflict)
+	}
+
+	r.softDelete(stored)
+	return nil
+}
+
+// BatchInsert - добавляет все задачи под одной блокировкой (атомарно)
+func (r *InMemoryTodoRepository) BatchInsert(ctx context.Context, todos []*model.Todo) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := r.now()
+	for _, todo := range todos {
+		r.insert(todo, now)
+	}
+
+	return nil
+}
+
+// GetByIDs - получает задачи по списку ID (новые первыми), отсутствующие ID пропускаются
+func (r *InMemoryTodoRepository) GetByIDs(ctx context.Context, ids []int64) ([]*model.Todo, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var todos []*model.Todo
+	seen := make(map[int64]bool, len(ids))
+	for _, id := range ids {
+		stored, ok := r.todos[id]
+		if !ok || stored.DeletedAt != nil || seen[id] {
+			continue
+		}
+		seen[id] = true
+
+		todos = append(todos, cloneTodo(stored))
+	}
+
+	sortTodos(todos, model.SortCreatedAt)
+	return todos, nil
+}
+
+// GetByIDsForUpdate - в памяти блокировать строки не нужно, задачи в порядке id
+func (r *InMemoryTodoRepository) GetByIDsForUpdate(ctx context.Context, ids []int64) ([]*model.Todo, error) {
+	todos, err := r.GetByIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	slices.SortFunc(todos, func(a, b *model.Todo) int { return cmp.Compare(a.ID, b.ID) })
+	return todos, nil
+}
+
+// CompleteByIDs - отмечает выполненными невыполненные задачи пользователя из списка
+func (r *InMemoryTodoRepository) CompleteByIDs(ctx context.Context, userID int64, ids []int64) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := r.now()
+	var n int64
+	for _, id := range ids {
+		stored, ok := r.todos[id]
+		if !ok || stored.UserID != userID || stored.Completed || stored.DeletedAt != nil {
+			continue
+		}
+
+		stored.Completed = true
+		stored.Version++
+		stored.UpdatedAt = now
+		n++
+	}
+
+	return n, nil
+}
+
+// DeleteByIDs - мягко удаляет задачи пользователя из списка
+func (r *InMemoryTodoRepository) DeleteByIDs(ctx context.Context, userID int64, ids []int64) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var n int64
+	for _, id := range ids {
+		stored, ok := r.todos[id]
+		if !ok || stored.UserID != userID || stored.DeletedAt != nil {
+			continue
+		}
+
+		r.softDelete(stored)
+		n++
+	}
+
+	return n, nil
+}
+
+// Restore - возвращает задачу пользователя из корзины
+func (r *InMemoryTodoRepository) Restore(ctx context.Context, userID, id int64) (*model.Todo, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.todos[id]
+	if !ok || stored.UserID != userID || stored.DeletedAt == nil {
+		return nil, fmt.Errorf("restore todo %d: %w", id, model.ErrTodoNotFound)
+	}
+
+	stored.DeletedAt = nil
+	stored.Version++
+	stored.UpdatedAt = r.now()
+
+	return cloneTodo(stored), nil
+}
+
+// PurgeDeleted - окончательно удаляет задачи, которые лежат в корзине дольше olderThan
+func (r *InMemoryTodoRepository) PurgeDeleted(ctx context.Context, olderThan time.Duration) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	before := r.now().Add(-olderThan)
+
+	purged := make(map[int64]bool)
+	for id, stored := range r.todos {
+		if stored.DeletedAt != nil && stored.DeletedAt.Before(before) {
+			delete(r.todos, id)
+			delete(r.events, id) // ON DELETE CASCADE
+			purged[id] = true
+		}
+	}
+
+	// parent_id - ON DELETE SET NULL, todo_dependencies - ON DELETE CASCADE
+	for _, stored := range r.todos {
+		if stored.ParentID != nil && purged[*stored.ParentID] {
+			stored.ParentID = nil
+		}
+		stored.BlockedBy = slices.DeleteFunc(stored.BlockedBy, func(id int64) bool { return purged[id] })
+	}
+
+	return int64(len(purged)), nil
+}
+
+// AddEvent - добавляет запись в историю изменений задачи
+func (r *InMemoryTodoRepository) AddEvent(ctx context.Context, event *model.TodoEvent) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.todos[event.TodoID]; !ok {
+		return fmt.Errorf("add event for todo %d: %w", event.TodoID, model.ErrTodoNotFound)
+	}
+
+	event.ID = r.nextEventID
+	event.CreatedAt = r.now()
+	r.nextEventID++
+
+	stored := *event
+	r.events[event.TodoID] = append(r.events[event.TodoID], &stored)
+	return nil
+}
+
+// ListEvents - история изменений задачи в хронологическом порядке
+func (r *InMemoryTodoRepository) ListEvents(ctx context.Context, todoID int64) ([]*model.TodoEvent, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	events := make([]*model.TodoEvent, 0, len(r.events[todoID]))
+	for _, stored := range r.events[todoID] {
+		event := *stored
+		events = append(events, &event)
+	}
+
+	return events, nil
+}
+
+// GetEvent - запись истории по ID
+func (r *InMemoryTodoRepository) GetEvent(ctx context.Context, id int64) (*model.TodoEvent, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, events := range r.events {
+		for _, stored := range events {
+			if stored.ID == id {
+				event := *stored
+				return &event, nil
+			}
+		}
+	}
+
+	return nil, fmt.Errorf("get event %d: %w", id, model.ErrNotFound)
+}
+
+// ListSubtree - потомки задачи всех уровней (без нее самой) в порядке создания
+// Как и в Postgres, обход идет и через удаленные задачи, но они не возвращаются
+func (r *InMemoryTodoRepository) ListSubtree(ctx context.Context, id int64) ([]*model.Todo, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	inSubtree := map[int64]bool{id: true}
+	for queue := []int64{id}; len(queue) > 0; queue = queue[1:] {
+		for _, stored := range r.todos {
+			if stored.ParentID != nil && *stored.ParentID == queue[0] && !inSubtree[stored.ID] {
+				inSubtree[stored.ID] = true
+				queue = append(queue, stored.ID)
+			}
+		}
+	}
+
+	var todos []*model.Todo
+	for todoID := range inSubtree {
+		if stored := r.todos[todoID]; todoID != id && stored.DeletedAt == nil {
+			todos = append(todos, cloneTodo(stored))
+		}
+	}
+
+	// ORDER BY created_at, id
+	slices.SortFunc(todos, func(a, b *model.Todo) int {
+		if c := a.CreatedAt.Compare(b.CreatedAt); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.ID, b.ID)
+	})
+
+	return todos, nil
+}
+
+// ListDependencies - граф зависимостей задач пользователя, включая задачи из корзины
+func (r *InMemoryTodoRepository) ListDependencies(ctx context.Context, userID int64) (map[int64][]int64, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	graph := make(map[int64][]int64)
+	for _, stored := range r.todos {
+		if stored.UserID == userID && len(stored.BlockedBy) > 0 {
+			graph[stored.ID] = slices.Clone(stored.BlockedBy)
+		}
+	}
+
+	return graph, nil
+}
+
+// LockDependencies - в памяти транзакции InMemoryTxManager и так выполняются по одной
+func (r *InMemoryTodoRepository) LockDependencies(ctx context.Context, userID int64) error {
+	return nil
+}
+
+// softDelete - помечает задачу удаленной, как UPDATE ... SET deleted_at в Postgres
+func (r *InMemoryTodoRepository) softDelete(stored *model.Todo) {
+	now := r.now()
+	stored.DeletedAt = &now
+	stored.Version++
+	stored.UpdatedAt = now
+}
+
+// insert - проставляет ID, версию, время и значения по умолчанию, как это делает база
+func (r *InMemoryTodoRepository) insert(todo *model.Todo, now time.Time) {
+	todo.ID = r.nextID
+	todo.Version = 1
+	todo.CreatedAt = now
+	todo.UpdatedAt = now
+	if todo.Priority == 0 {
+		todo.Priority = model.PriorityNormal
+	}
+	todo.Tags = sortedTags(todo.Tags)
+	todo.BlockedBy = sortedIDs(todo.BlockedBy)
+	r.nextID++
+
+	r.todos[todo.ID] = cloneTodo(todo)
+}
+
+// cloneTodo - копия задачи вместе со slice тегов и зависимостей
+func cloneTodo(todo *model.Todo) *model.Todo {
+	clone := *todo
+	clone.Tags = append([]string{}, todo.Tags...)
+	clone.BlockedBy = append([]int64{}, todo.BlockedBy...)
+	return &clone
+}
+
+// sortedTags - теги по алфавиту, как ORDER BY t.name в loadTags
+func sortedTags(tags []string) []string {
+	sorted := append([]string{}, tags...)
+	sort.Strings(sorted)
+	return sorted
+}
+
+// sortedIDs - ID по возрастанию, как ORDER BY blocked_by_id в loadBlockers
+func sortedIDs(ids []int64) []int64 {
+	sorted := append([]int64{}, ids...)
+	slices.Sort(sorted)
+	return sorted
+}
+
+// sortTodos - ORDER BY из orderBy для сортировки order
+func sortTodos(todos []*model.Todo, order model.TodoSort) {
+	slices.SortFunc(todos, func(a, b *model.Todo) int {
+		switch {
+		case comesAfter(a, b, order):
+			return 1
+		case comesAfter(b, a, order):
+			return -1
+		}
+		return 0
+	})
+}
+
+// comesAfter - a идет в списке после b (аналог keysetCondition)
+func comesAfter(a, b *model.Todo, order model.TodoSort) bool {
+	switch order {
+	case model.SortDueAt:
+		// due_at ASC NULLS LAST, id ASC
+		switch {
+		case a.DueAt == nil && b.DueAt == nil:
+			return a.ID > b.ID
+		case a.DueAt == nil:
+			return true
+		case b.DueAt == nil:
+			return false
+		case !a.DueAt.Equal(*b.DueAt):
+			return a.DueAt.After(*b.DueAt)
+		}
+		return a.ID > b.ID
+	case model.SortPriority:
+		// priority DESC, id DESC
+		if a.Priority != b.Priority {
+			return a.Priority < b.Priority
+		}
+		return a.ID < b.ID
+	default:
+		// created_at DESC, id DESC
+		if !a.CreatedAt.Equal(b.CreatedAt) {
+			return a.CreatedAt.Before(b.CreatedAt)
+		}
+		return a.ID < b.ID
+	}
+}
+
+// cursorTodo - курсор в виде задачи, чтобы сравнивать его через comesAfter
+func cursorTodo(cursor *model.TodoCursor) *model.Todo {
+	return &model.Todo{
+		ID:        cursor.ID,
+		CreatedAt: cursor.CreatedAt,
+		DueAt:     cursor.DueAt,
+		Priority:  cursor.Priority,
+	}
+}
+
+// highlight - оборачивает вхождения слов (без учета регистра) в маркеры подсветки
+func highlight(text string, words []string) string {
+	lower := strings.ToLower(text)
+	if len(lower) != len(text) {
+		// Смена регистра изменила длину в байтах: позиции не совпадут, оставляем без подсветки
+		return text
+	}
+
+	marked := make([]bool, len(text))
+	for _, word := range words {
+		for start := 0; ; {
+			i := strings.Index(lower[start:], word)
+			if i < 0 {
+				break
+			}
+			for j := start + i; j < start+i+len(word); j++ {
+				marked[j] = true
+			}
+			start += i + len(word)
+		}
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(text); i++ {
+		if marked[i] && (i == 0 || !marked[i-1]) {
+			b.WriteString(model.HighlightStart)
+		}
+		b.WriteByte(text[i])
+		if marked[i] && (i == len(text)-1 || !marked[i+1]) {
+			b.WriteString(model.HighlightStop)
+		}
+	}
+
+	return b.String()
+}
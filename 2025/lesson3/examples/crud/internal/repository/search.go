@@ -0,0 +1,65 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"crud-example/internal/model"
+)
+
+// Настройки ts_headline: маркеры подсветки из model и размер фрагментов описания
+var (
+	titleHeadlineOptions = fmt.Sprintf(`StartSel="%s", StopSel="%s", HighlightAll=true`,
+		model.HighlightStart, model.HighlightStop)
+	descriptionHeadlineOptions = fmt.Sprintf(`StartSel="%s", StopSel="%s", MaxFragments=2, MaxWords=20, MinWords=5`,
+		model.HighlightStart, model.HighlightStop)
+)
+
+// Search - полнотекстовый поиск по заголовку и описанию задач пользователя
+// query разбирается websearch_to_tsquery: слова через пробел - И, "фраза в кавычках", or, -исключить.
+// Результаты отсортированы по релевантности (ts_rank), индекс GIN по search_vector
+func (r *PostgresTodoRepository) Search(ctx context.Context, userID int64, query string, limit int) ([]*model.TodoSearchHit, error) {
+	sqlQuery := `
+		SELECT id, user_id, title, description, completed, due_at, priority, recurrence, parent_id, version, created_at, updated_at, deleted_at,
+		       ts_rank(search_vector, q) AS rank,
+		       ts_headline('russian', title, q, $4) AS title_snippet,
+		       ts_headline('russian', coalesce(description, ''), q, $5) AS description_snippet
+		FROM todos, websearch_to_tsquery('russian', $2) AS q
+		WHERE user_id = $1 AND deleted_at IS NULL AND search_vector @@ q
+		ORDER BY rank DESC, id DESC
+		LIMIT $3
+	`
+
+	// sqlx раскладывает колонки и во встроенную структуру model.Todo
+	var rows []struct {
+		model.Todo
+		Rank               float64 `db:"rank"`
+		TitleSnippet       string  `db:"title_snippet"`
+		DescriptionSnippet string  `db:"description_snippet"`
+	}
+
+	err := r.db.SelectContext(ctx, &rows, sqlQuery, userID, query, limit,
+		titleHeadlineOptions, descriptionHeadlineOptions)
+	if err != nil {
+		return nil, fmt.Errorf("search todos of user %d: %w", userID, err)
+	}
+
+	hits := make([]*model.TodoSearchHit, 0, len(rows))
+	todos := make([]*model.Todo, 0, len(rows))
+	for i := range rows {
+		todo := &rows[i].Todo
+		todos = append(todos, todo)
+		hits = append(hits, &model.TodoSearchHit{
+			Todo:               todo,
+			Rank:               rows[i].Rank,
+			TitleSnippet:       rows[i].TitleSnippet,
+			DescriptionSnippet: rows[i].DescriptionSnippet,
+		})
+	}
+
+	if err := r.loadRelations(ctx, todos...); err != nil {
+		return nil, fmt.Errorf("search todos of user %d: %w", userID, err)
+	}
+
+	return hits, nil
+}
@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+
+	"crud-example/internal/model"
+)
+
+// setTags - заменяет теги задачи на todo.Tags
+// Одним запросом: недостающие теги пользователя создаются, лишние связи удаляются.
+// Имена тегов должны быть без повторов (их нормализует сервис), иначе
+// ON CONFLICT DO UPDATE не сможет обновить одну строку дважды
+func (r *PostgresTodoRepository) setTags(ctx context.Context, todo *model.Todo) error {
+	tags := todo.Tags
+	if tags == nil {
+		tags = []string{}
+	}
+
+	// DO UPDATE (а не DO NOTHING), чтобы RETURNING вернул id и уже существующих тегов
+	query := `
+		WITH tag_ids AS (
+			INSERT INTO tags (user_id, name)
+			SELECT $1, unnest($3::text[])
+			ON CONFLICT (user_id, name) DO UPDATE SET name = EXCLUDED.name
+			RETURNING id
+		), removed AS (
+			DELETE FROM todo_tags
+			WHERE todo_id = $2 AND tag_id NOT IN (SELECT id FROM tag_ids)
+		)
+		INSERT INTO todo_tags (todo_id, tag_id)
+		SELECT $2, id FROM tag_ids
+		ON CONFLICT DO NOTHING
+	`
+
+	if _, err := r.db.ExecContext(ctx, query, todo.UserID, todo.ID, tags); err != nil {
+		return fmt.Errorf("set tags: %w", mapError(err))
+	}
+
+	return nil
+}
+
+// loadTags - заполняет Tags у задач одним запросом (без N+1)
+func (r *PostgresTodoRepository) loadTags(ctx context.Context, todos ...*model.Todo) error {
+	if len(todos) == 0 {
+		return nil
+	}
+
+	byID := make(map[int64]*model.Todo, len(todos))
+	ids := make([]int64, 0, len(todos))
+	for _, todo := range todos {
+		todo.Tags = []string{}
+		byID[todo.ID] = todo
+		ids = append(ids, todo.ID)
+	}
+
+	query, args, err := sqlx.In(`
+		SELECT tt.todo_id, t.name
+		FROM todo_tags tt
+		JOIN tags t ON t.id = tt.tag_id
+		WHERE tt.todo_id IN (?)
+		ORDER BY t.name
+	`, ids)
+	if err != nil {
+		return fmt.Errorf("load tags: %w", err)
+	}
+
+	var rows []struct {
+		TodoID int64  `db:"todo_id"`
+		Name   string `db:"name"`
+	}
+	if err := r.db.SelectContext(ctx, &rows, r.db.Rebind(query), args...); err != nil {
+		return fmt.Errorf("load tags: %w", err)
+	}
+
+	for _, row := range rows {
+		byID[row.TodoID].Tags = append(byID[row.TodoID].Tags, row.Name)
+	}
+
+	return nil
+}
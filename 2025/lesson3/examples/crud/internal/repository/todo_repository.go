@@ -2,8 +2,13 @@ package repository
 
 import (
 	"context"
+	"database/sql"
 	"errors"
+	"fmt"
+	"strings"
+	"time"
 
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jmoiron/sqlx"
 
 	"crud-example/internal/model"
@@ -13,14 +18,40 @@ import (
 type TodoRepository interface {
 	Create(ctx context.Context, todo *model.Todo) (int64, error)
 	GetByID(ctx context.Context, id int64) (*model.Todo, error)
+	GetByIDForUpdate(ctx context.Context, id int64) (*model.Todo, error)
 	GetAllByUserID(ctx context.Context, userID int64) ([]*model.Todo, error)
+	List(ctx context.Context, userID int64, filter model.TodoFilter) ([]*model.Todo, error)
+	Search(ctx context.Context, userID int64, query string, limit int) ([]*model.TodoSearchHit, error)
 	Update(ctx context.Context, todo *model.Todo) error
-	Delete(ctx context.Context, id int64) error
+	Delete(ctx context.Context, id, version int64) error
+	BatchInsert(ctx context.Context, todos []*model.Todo) error
+	GetByIDs(ctx context.Context, ids []int64) ([]*model.Todo, error)
+	GetByIDsForUpdate(ctx context.Context, ids []int64) ([]*model.Todo, error)
+	CompleteByIDs(ctx context.Context, userID int64, ids []int64) (int64, error)
+	DeleteByIDs(ctx context.Context, userID int64, ids []int64) (int64, error)
+	Restore(ctx context.Context, userID, id int64) (*model.Todo, error)
+	PurgeDeleted(ctx context.Context, olderThan time.Duration) (int64, error)
+	AddEvent(ctx context.Context, event *model.TodoEvent) error
+	ListEvents(ctx context.Context, todoID int64) ([]*model.TodoEvent, error)
+	GetEvent(ctx context.Context, id int64) (*model.TodoEvent, error)
+	ListSubtree(ctx context.Context, id int64) ([]*model.Todo, error)
+	ListDependencies(ctx context.Context, userID int64) (map[int64][]int64, error)
+	LockDependencies(ctx context.Context, userID int64) error
+}
+
+// dbtx - общие методы *sqlx.DB и *sqlx.Tx
+// Репозиторий работает одинаково и с пулом соединений, и внутри транзакции
+type dbtx interface {
+	sqlx.ExtContext
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+	GetContext(ctx context.Context, dest any, query string, args ...any) error
+	SelectContext(ctx context.Context, dest any, query string, args ...any) error
+	NamedExecContext(ctx context.Context, query string, arg any) (sql.Result, error)
 }
 
 // PostgresTodoRepository - реализация для PostgreSQL с использованием sqlx
 type PostgresTodoRepository struct {
-	db *sqlx.DB
+	db dbtx
 }
 
 // NewTodoRepository - создает новый репозиторий
@@ -29,11 +60,17 @@ func NewTodoRepository(db *sqlx.DB) TodoRepository {
 }
 
 // Create - добавляет новую задачу в БД
+// Теги и зависимости пишутся отдельными запросами: чтобы задача сохранилась атомарно
+// вместе с ними, вызывайте Create внутри TxManager.WithinTx
 func (r *PostgresTodoRepository) Create(ctx context.Context, todo *model.Todo) (int64, error) {
+	if todo.Priority == 0 {
+		todo.Priority = model.PriorityNormal
+	}
+
 	query := `
-		INSERT INTO todos (user_id, title, description, completed)
-		VALUES ($1, $2, $3, $4)
-		RETURNING id, created_at, updated_at
+		INSERT INTO todos (user_id, title, description, completed, due_at, priority, recurrence, parent_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id, version, created_at, updated_at
 	`
 
 	// sqlx.QueryRowxContext возвращает *sqlx.Row с методом StructScan
@@ -45,10 +82,26 @@ func (r *PostgresTodoRepository) Create(ctx context.Context, todo *model.Todo) (
 		todo.Title,
 		todo.Description,
 		todo.Completed,
-	).Scan(&todo.ID, &todo.CreatedAt, &todo.UpdatedAt)
+		todo.DueAt,
+		todo.Priority,
+		todo.Recurrence,
+		todo.ParentID,
+	).Scan(&todo.ID, &todo.Version, &todo.CreatedAt, &todo.UpdatedAt)
 
 	if err != nil {
-		return 0, err
+		return 0, fmt.Errorf("create todo: %w", mapError(err))
+	}
+
+	if len(todo.Tags) > 0 {
+		if err := r.setTags(ctx, todo); err != nil {
+			return 0, fmt.Errorf("create todo: %w", err)
+		}
+	}
+
+	if len(todo.BlockedBy) > 0 {
+		if err := r.setBlockers(ctx, todo); err != nil {
+			return 0, fmt.Errorf("create todo: %w", err)
+		}
 	}
 
 	return todo.ID, nil
@@ -58,9 +111,9 @@ func (r *PostgresTodoRepository) Create(ctx context.Context, todo *model.Todo) (
 // Используем sqlx.Get для автоматического маппинга в структуру
 func (r *PostgresTodoRepository) GetByID(ctx context.Context, id int64) (*model.Todo, error) {
 	query := `
-		SELECT id, user_id, title, description, completed, created_at, updated_at
+		SELECT id, user_id, title, description, completed, due_at, priority, recurrence, parent_id, version, created_at, updated_at, deleted_at
 		FROM todos
-		WHERE id = $1
+		WHERE id = $1 AND deleted_at IS NULL
 	`
 
 	todo := &model.Todo{}
@@ -68,10 +121,43 @@ func (r *PostgresTodoRepository) GetByID(ctx context.Context, id int64) (*model.
 	// sqlx.Get автоматически делает Scan в структуру благодаря тегам `db`
 	err := r.db.GetContext(ctx, todo, query, id)
 	if err != nil {
-		if err.Error() == "sql: no rows in result set" {
-			return nil, errors.New("todo not found")
+		// sql.ErrNoRows - стандартная ошибка "строка не найдена",
+		// проверяем через errors.Is, а не сравнением строк
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("get todo %d: %w", id, model.ErrTodoNotFound)
 		}
-		return nil, err
+		return nil, fmt.Errorf("get todo %d: %w", id, err)
+	}
+
+	if err := r.loadRelations(ctx, todo); err != nil {
+		return nil, fmt.Errorf("get todo %d: %w", id, err)
+	}
+
+	return todo, nil
+}
+
+// GetByIDForUpdate - получает задачу и блокирует строку до конца транзакции (SELECT ... FOR UPDATE)
+// Конкурентная транзакция, которая хочет изменить ту же задачу, будет ждать COMMIT/ROLLBACK.
+// Вне транзакции блокировка снимается сразу после запроса
+func (r *PostgresTodoRepository) GetByIDForUpdate(ctx context.Context, id int64) (*model.Todo, error) {
+	query := `
+		SELECT id, user_id, title, description, completed, due_at, priority, recurrence, parent_id, version, created_at, updated_at, deleted_at
+		FROM todos
+		WHERE id = $1 AND deleted_at IS NULL
+		FOR UPDATE
+	`
+
+	todo := &model.Todo{}
+	err := r.db.GetContext(ctx, todo, query, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("get todo %d for update: %w", id, model.ErrTodoNotFound)
+		}
+		return nil, fmt.Errorf("get todo %d for update: %w", id, err)
+	}
+
+	if err := r.loadRelations(ctx, todo); err != nil {
+		return nil, fmt.Errorf("get todo %d for update: %w", id, err)
 	}
 
 	return todo, nil
@@ -81,10 +167,10 @@ func (r *PostgresTodoRepository) GetByID(ctx context.Context, id int64) (*model.
 // Используем sqlx.Select для автоматического маппинга slice
 func (r *PostgresTodoRepository) GetAllByUserID(ctx context.Context, userID int64) ([]*model.Todo, error) {
 	query := `
-		SELECT id, user_id, title, description, completed, created_at, updated_at
+		SELECT id, user_id, title, description, completed, due_at, priority, recurrence, parent_id, version, created_at, updated_at, deleted_at
 		FROM todos
-		WHERE user_id = $1
-		ORDER BY created_at DESC
+		WHERE user_id = $1 AND deleted_at IS NULL
+		ORDER BY created_at DESC, id DESC
 	`
 
 	var todos []*model.Todo
@@ -93,37 +179,173 @@ func (r *PostgresTodoRepository) GetAllByUserID(ctx context.Context, userID int6
 	// НЕ НУЖНО вручную делать rows.Scan() в цикле!
 	err := r.db.SelectContext(ctx, &todos, query, userID)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("list todos of user %d: %w", userID, err)
+	}
+
+	if err := r.loadRelations(ctx, todos...); err != nil {
+		return nil, fmt.Errorf("list todos of user %d: %w", userID, err)
+	}
+
+	return todos, nil
+}
+
+// List - получает задачи пользователя с фильтрами и keyset-пагинацией
+// Условия WHERE собираются динамически, placeholder'ы ? переводятся в $1, $2... через Rebind
+func (r *PostgresTodoRepository) List(ctx context.Context, userID int64, filter model.TodoFilter) ([]*model.Todo, error) {
+	conditions := []string{"user_id = ?"}
+	args := []interface{}{userID}
+
+	// Удаленные задачи видны только в корзине (filter.Deleted)
+	if filter.Deleted {
+		conditions = append(conditions, "deleted_at IS NOT NULL")
+	} else {
+		conditions = append(conditions, "deleted_at IS NULL")
+	}
+
+	if filter.Completed != nil {
+		conditions = append(conditions, "completed = ?")
+		args = append(args, *filter.Completed)
+	}
+
+	if filter.TitleContains != "" {
+		conditions = append(conditions, "title ILIKE ?")
+		args = append(args, "%"+escapeLike(filter.TitleContains)+"%")
+	}
+
+	if !filter.CreatedFrom.IsZero() {
+		conditions = append(conditions, "created_at >= ?")
+		args = append(args, filter.CreatedFrom)
+	}
+
+	if !filter.CreatedTo.IsZero() {
+		conditions = append(conditions, "created_at < ?")
+		args = append(args, filter.CreatedTo)
+	}
+
+	if !filter.DueFrom.IsZero() {
+		conditions = append(conditions, "due_at >= ?")
+		args = append(args, filter.DueFrom)
+	}
+
+	if !filter.DueTo.IsZero() {
+		conditions = append(conditions, "due_at < ?")
+		args = append(args, filter.DueTo)
+	}
+
+	if filter.Priority != nil {
+		conditions = append(conditions, "priority = ?")
+		args = append(args, *filter.Priority)
+	}
+
+	if filter.Tag != "" {
+		conditions = append(conditions, `EXISTS (
+			SELECT 1 FROM todo_tags tt JOIN tags t ON t.id = tt.tag_id
+			WHERE tt.todo_id = todos.id AND t.name = ?)`)
+		args = append(args, filter.Tag)
+	}
+
+	// Keyset pagination: вместо OFFSET продолжаем строго после последней
+	// показанной строки. Условие зависит от сортировки, см. keysetCondition
+	if filter.After != nil {
+		cond, condArgs := keysetCondition(filter.Sort, filter.After)
+		conditions = append(conditions, cond)
+		args = append(args, condArgs...)
+	}
+
+	query := `
+		SELECT id, user_id, title, description, completed, due_at, priority, recurrence, parent_id, version, created_at, updated_at, deleted_at
+		FROM todos
+		WHERE ` + strings.Join(conditions, " AND ") + `
+		ORDER BY ` + orderBy(filter.Sort) + `
+	`
+
+	// Limit == 0 - без ограничения
+	if filter.Limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, filter.Limit)
+	}
+
+	var todos []*model.Todo
+	err := r.db.SelectContext(ctx, &todos, r.db.Rebind(query), args...)
+	if err != nil {
+		return nil, fmt.Errorf("list todos of user %d: %w", userID, err)
+	}
+
+	if err := r.loadRelations(ctx, todos...); err != nil {
+		return nil, fmt.Errorf("list todos of user %d: %w", userID, err)
 	}
 
 	return todos, nil
 }
 
-// Update - обновляет задачу
+// orderBy - ORDER BY для сортировки списка (должен совпадать с keysetCondition)
+func orderBy(sort model.TodoSort) string {
+	switch sort {
+	case model.SortDueAt:
+		return "due_at ASC NULLS LAST, id ASC"
+	case model.SortPriority:
+		return "priority DESC, id DESC"
+	default:
+		return "created_at DESC, id DESC"
+	}
+}
+
+// keysetCondition - условие "строка идет после курсора" для сортировки sort
+// Сравнение кортежей (a, id) < (?, ?) использует индексы (user_id, created_at, id)
+// и (user_id, priority, id). due_at может быть NULL, поэтому для него условие длиннее
+func keysetCondition(sort model.TodoSort, after *model.TodoCursor) (string, []interface{}) {
+	switch sort {
+	case model.SortDueAt:
+		// NULLS LAST: после задач со сроком идут задачи без срока
+		if after.DueAt == nil {
+			return "(due_at IS NULL AND id > ?)", []interface{}{after.ID}
+		}
+		return "(due_at > ? OR (due_at = ? AND id > ?) OR due_at IS NULL)",
+			[]interface{}{*after.DueAt, *after.DueAt, after.ID}
+	case model.SortPriority:
+		return "(priority, id) < (?, ?)", []interface{}{after.Priority, after.ID}
+	default:
+		return "(created_at, id) < (?, ?)", []interface{}{after.CreatedAt, after.ID}
+	}
+}
+
+// Update - обновляет задачу (оптимистичная блокировка)
+// Строка обновляется, только если ее version не изменилась с момента чтения (todo.Version).
+// RETURNING возвращает новые updated_at и version, проставленные базой
+// Теги и зависимости заменяются целиком отдельными запросами, поэтому Update нужно вызывать внутри WithinTx
 func (r *PostgresTodoRepository) Update(ctx context.Context, todo *model.Todo) error {
 	query := `
 		UPDATE todos
-		SET title = $1, description = $2, completed = $3, updated_at = CURRENT_TIMESTAMP
-		WHERE id = $4
+		SET title = $1, description = $2, completed = $3, due_at = $4, priority = $5, recurrence = $6,
+		    parent_id = $7, version = version + 1, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $8 AND version = $9 AND deleted_at IS NULL
+		RETURNING version, updated_at
 	`
 
-	result, err := r.db.ExecContext(ctx, query,
+	err := r.db.QueryRowContext(ctx, query,
 		todo.Title,
 		todo.Description,
 		todo.Completed,
+		todo.DueAt,
+		todo.Priority,
+		todo.Recurrence,
+		todo.ParentID,
 		todo.ID,
-	)
+		todo.Version,
+	).Scan(&todo.Version, &todo.UpdatedAt)
 	if err != nil {
-		return err
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("update todo %d: %w", todo.ID, r.missingOrStale(ctx, todo.ID))
+		}
+		return fmt.Errorf("update todo %d: %w", todo.ID, mapError(err))
 	}
 
-	rowsAffected, err := result.RowsAffected()
-	if err != nil {
-		return err
+	if err := r.setTags(ctx, todo); err != nil {
+		return fmt.Errorf("update todo %d: %w", todo.ID, err)
 	}
 
-	if rowsAffected == 0 {
-		return errors.New("todo not found")
+	if err := r.setBlockers(ctx, todo); err != nil {
+		return fmt.Errorf("update todo %d: %w", todo.ID, err)
 	}
 
 	return nil
@@ -137,74 +359,169 @@ func (r *PostgresTodoRepository) UpdateNamed(ctx context.Context, todo *model.To
 		SET title = :title,
 		    description = :description,
 		    completed = :completed,
+		    due_at = :due_at,
+		    priority = :priority,
+		    recurrence = :recurrence,
+		    parent_id = :parent_id,
+		    version = version + 1,
 		    updated_at = CURRENT_TIMESTAMP
-		WHERE id = :id
+		WHERE id = :id AND version = :version AND deleted_at IS NULL
 	`
 
 	// NamedExecContext использует теги `db` из структуры
 	result, err := r.db.NamedExecContext(ctx, query, todo)
 	if err != nil {
-		return err
+		return fmt.Errorf("update todo %d: %w", todo.ID, mapError(err))
 	}
 
-	rowsAffected, err := result.RowsAffected()
-	if err != nil {
+	if err := r.checkVersioned(ctx, result, todo.ID); err != nil {
 		return err
 	}
 
-	if rowsAffected == 0 {
-		return errors.New("todo not found")
+	todo.Version++
+	return nil
+}
+
+// Delete - мягко удаляет задачу (проставляет deleted_at), если ее версия совпадает с ожидаемой
+// Строка остается в таблице: задачу можно восстановить через Restore,
+// пока ее не удалит окончательно PurgeDeleted
+func (r *PostgresTodoRepository) Delete(ctx context.Context, id, version int64) error {
+	query := `
+		UPDATE todos
+		SET deleted_at = CURRENT_TIMESTAMP, version = version + 1, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND version = $2 AND deleted_at IS NULL
+	`
+
+	result, err := r.db.ExecContext(ctx, query, id, version)
+	if err != nil {
+		return fmt.Errorf("delete todo %d: %w", id, err)
 	}
 
-	return nil
+	return r.checkVersioned(ctx, result, id)
+}
+
+// batchInsertColumns - колонки, которые BatchInsert передает параметрами для каждой задачи
+var batchInsertColumns = [...]string{
+	"id", "user_id", "title", "description", "completed", "due_at", "priority", "recurrence", "parent_id",
 }
 
-// Delete - удаляет задачу
-func (r *PostgresTodoRepository) Delete(ctx context.Context, id int64) error {
-	query := `DELETE FROM todos WHERE id = $1`
+// maxQueryParams - лимит PostgreSQL на количество параметров в одном запросе
+const maxQueryParams = 65535
 
-	result, err := r.db.ExecContext(ctx, query, id)
+// MaxBatchInsertSize - сколько задач помещается в один BatchInsert (ограничение по параметрам)
+const MaxBatchInsertSize = maxQueryParams / len(batchInsertColumns)
+
+// BatchInsert - массовая вставка одним INSERT ... VALUES (...), (...), ...
+// Один SQL-запрос атомарен: либо вставятся все задачи, либо ни одной.
+// Порядок строк RETURNING PostgreSQL не гарантирует, а сослаться в RETURNING на номер строки
+// из VALUES нельзя, поэтому ID выдаются заранее из последовательности и строки сопоставляются по ним
+func (r *PostgresTodoRepository) BatchInsert(ctx context.Context, todos []*model.Todo) error {
+	if len(todos) == 0 {
+		return nil
+	}
+	if len(todos) > MaxBatchInsertSize {
+		return fmt.Errorf("batch insert todos: %d todos, max %d per query", len(todos), MaxBatchInsertSize)
+	}
+
+	for _, todo := range todos {
+		if todo.Priority == 0 {
+			todo.Priority = model.PriorityNormal
+		}
+	}
+
+	// ORDER BY: ID растут в порядке входных задач, как при вставке по одной
+	var ids []int64
+	err := r.db.SelectContext(ctx, &ids, `
+		SELECT nextval(pg_get_serial_sequence('todos', 'id')) AS id
+		FROM generate_series(1, $1)
+		ORDER BY id
+	`, len(todos))
 	if err != nil {
-		return err
+		return fmt.Errorf("batch insert todos: reserve ids: %w", err)
 	}
 
-	rowsAffected, err := result.RowsAffected()
+	byID := make(map[int64]*model.Todo, len(todos))
+	for i, todo := range todos {
+		todo.ID = ids[i]
+		byID[todo.ID] = todo
+	}
+
+	query := fmt.Sprintf(`
+		INSERT INTO todos (%s)
+		VALUES (:%s)
+		RETURNING id, version, created_at, updated_at
+	`, strings.Join(batchInsertColumns[:], ", "), strings.Join(batchInsertColumns[:], ", :"))
+
+	// NamedQuery, как и NamedExec, может принимать slice структур
+	// У *sqlx.Tx нет метода NamedQueryContext, поэтому используем функцию пакета
+	rows, err := sqlx.NamedQueryContext(ctx, r.db, query, todos)
 	if err != nil {
-		return err
+		return fmt.Errorf("batch insert todos: %w", mapError(err))
 	}
+	defer rows.Close()
+
+	returned := 0
+	for rows.Next() {
+		var (
+			id      int64
+			version int64
+			created time.Time
+			updated time.Time
+		)
+		if err := rows.Scan(&id, &version, &created, &updated); err != nil {
+			return fmt.Errorf("batch insert todos: %w", err)
+		}
 
-	if rowsAffected == 0 {
-		return errors.New("todo not found")
+		todo, ok := byID[id]
+		if !ok {
+			return fmt.Errorf("batch insert todos: unexpected id %d in RETURNING", id)
+		}
+		todo.Version, todo.CreatedAt, todo.UpdatedAt = version, created, updated
+		returned++
 	}
 
-	return nil
-}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("batch insert todos: %w", mapError(err))
+	}
+	if returned != len(todos) {
+		return fmt.Errorf("batch insert todos: %d rows returned for %d todos", returned, len(todos))
+	}
+	rows.Close()
 
-// BatchInsert - пример массовой вставки с sqlx
-func (r *PostgresTodoRepository) BatchInsert(ctx context.Context, todos []*model.Todo) error {
-	query := `
-		INSERT INTO todos (user_id, title, description, completed)
-		VALUES (:user_id, :title, :description, :completed)
-	`
+	for _, todo := range todos {
+		if len(todo.Tags) > 0 {
+			if err := r.setTags(ctx, todo); err != nil {
+				return fmt.Errorf("batch insert todos: %w", err)
+			}
+		}
+		if len(todo.BlockedBy) > 0 {
+			if err := r.setBlockers(ctx, todo); err != nil {
+				return fmt.Errorf("batch insert todos: %w", err)
+			}
+		}
+	}
 
-	// NamedExec может принимать slice структур
-	_, err := r.db.NamedExecContext(ctx, query, todos)
-	return err
+	return nil
 }
 
-// GetWithRawSQL - пример использования sqlx.In для запросов с IN (...)
+// GetByIDs - пример использования sqlx.In для запросов с IN (...)
 func (r *PostgresTodoRepository) GetByIDs(ctx context.Context, ids []int64) ([]*model.Todo, error) {
+	// sqlx.In не принимает пустой slice: IN () - синтаксическая ошибка
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
 	query := `
-		SELECT id, user_id, title, description, completed, created_at, updated_at
+		SELECT id, user_id, title, description, completed, due_at, priority, recurrence, parent_id, version, created_at, updated_at, deleted_at
 		FROM todos
-		WHERE id IN (?)
-		ORDER BY created_at DESC
+		WHERE id IN (?) AND deleted_at IS NULL
+		ORDER BY created_at DESC, id DESC
 	`
 
 	// sqlx.In преобразует ? в $1, $2, $3 для PostgreSQL
 	query, args, err := sqlx.In(query, ids)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("get todos by ids: %w", err)
 	}
 
 	// Rebind для правильных placeholder'ов PostgreSQL ($1, $2...)
@@ -215,8 +532,205 @@ func (r *PostgresTodoRepository) GetByIDs(ctx context.Context, ids []int64) ([]*
 	var todos []*model.Todo
 	err = r.db.SelectContext(ctx, &todos, query, args...)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("get todos by ids: %w", err)
+	}
+
+	if err := r.loadRelations(ctx, todos...); err != nil {
+		return nil, fmt.Errorf("get todos by ids: %w", err)
+	}
+
+	return todos, nil
+}
+
+// GetByIDsForUpdate - как GetByIDs, но блокирует строки до конца транзакции (SELECT ... FOR UPDATE)
+// Строки блокируются и возвращаются в порядке id: две транзакции с пересекающимися
+// списками ждут друг друга, а не получают deadlock
+func (r *PostgresTodoRepository) GetByIDsForUpdate(ctx context.Context, ids []int64) ([]*model.Todo, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	query, args, err := sqlx.In(`
+		SELECT id, user_id, title, description, completed, due_at, priority, recurrence, parent_id, version, created_at, updated_at, deleted_at
+		FROM todos
+		WHERE id IN (?) AND deleted_at IS NULL
+		ORDER BY id
+		FOR UPDATE
+	`, ids)
+	if err != nil {
+		return nil, fmt.Errorf("get todos by ids for update: %w", err)
+	}
+
+	var todos []*model.Todo
+	if err := r.db.SelectContext(ctx, &todos, r.db.Rebind(query), args...); err != nil {
+		return nil, fmt.Errorf("get todos by ids for update: %w", err)
+	}
+
+	if err := r.loadRelations(ctx, todos...); err != nil {
+		return nil, fmt.Errorf("get todos by ids for update: %w", err)
 	}
 
 	return todos, nil
 }
+
+// CompleteByIDs - отмечает выполненными задачи пользователя из списка
+// Условие user_id = ? гарантирует, что чужие задачи не будут затронуты.
+// Возвращает количество задач, которые были не выполнены и стали выполненными
+func (r *PostgresTodoRepository) CompleteByIDs(ctx context.Context, userID int64, ids []int64) (int64, error) {
+	if len(ids) == 0 {
+		return 0, nil
+	}
+
+	query, args, err := sqlx.In(`
+		UPDATE todos
+		SET completed = TRUE, version = version + 1, updated_at = CURRENT_TIMESTAMP
+		WHERE user_id = ? AND id IN (?) AND completed = FALSE AND deleted_at IS NULL
+	`, userID, ids)
+	if err != nil {
+		return 0, fmt.Errorf("complete todos: %w", err)
+	}
+
+	result, err := r.db.ExecContext(ctx, r.db.Rebind(query), args...)
+	if err != nil {
+		return 0, fmt.Errorf("complete todos: %w", err)
+	}
+
+	return result.RowsAffected()
+}
+
+// DeleteByIDs - мягко удаляет задачи пользователя из списка, возвращает количество удаленных
+func (r *PostgresTodoRepository) DeleteByIDs(ctx context.Context, userID int64, ids []int64) (int64, error) {
+	if len(ids) == 0 {
+		return 0, nil
+	}
+
+	query, args, err := sqlx.In(`
+		UPDATE todos
+		SET deleted_at = CURRENT_TIMESTAMP, version = version + 1, updated_at = CURRENT_TIMESTAMP
+		WHERE user_id = ? AND id IN (?) AND deleted_at IS NULL
+	`, userID, ids)
+	if err != nil {
+		return 0, fmt.Errorf("delete todos: %w", err)
+	}
+
+	result, err := r.db.ExecContext(ctx, r.db.Rebind(query), args...)
+	if err != nil {
+		return 0, fmt.Errorf("delete todos: %w", err)
+	}
+
+	return result.RowsAffected()
+}
+
+// Restore - возвращает задачу пользователя из корзины
+// Если задачи нет в корзине (не удалена, удалена окончательно или чужая), возвращает ErrTodoNotFound
+func (r *PostgresTodoRepository) Restore(ctx context.Context, userID, id int64) (*model.Todo, error) {
+	query := `
+		UPDATE todos
+		SET deleted_at = NULL, version = version + 1, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND user_id = $2 AND deleted_at IS NOT NULL
+		RETURNING id, user_id, title, description, completed, due_at, priority, recurrence, parent_id, version, created_at, updated_at, deleted_at
+	`
+
+	todo := &model.Todo{}
+	err := r.db.GetContext(ctx, todo, query, id, userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("restore todo %d: %w", id, model.ErrTodoNotFound)
+		}
+		return nil, fmt.Errorf("restore todo %d: %w", id, err)
+	}
+
+	if err := r.loadRelations(ctx, todo); err != nil {
+		return nil, fmt.Errorf("restore todo %d: %w", id, err)
+	}
+
+	return todo, nil
+}
+
+// PurgeDeleted - окончательно удаляет задачи, которые лежат в корзине дольше olderThan
+// Граница считается в базе (CURRENT_TIMESTAMP), как и сам deleted_at, поэтому
+// часовой пояс приложения не влияет на результат
+func (r *PostgresTodoRepository) PurgeDeleted(ctx context.Context, olderThan time.Duration) (int64, error) {
+	query := `
+		DELETE FROM todos
+		WHERE deleted_at IS NOT NULL
+		  AND deleted_at < CURRENT_TIMESTAMP - make_interval(secs => $1)
+	`
+
+	result, err := r.db.ExecContext(ctx, query, olderThan.Seconds())
+	if err != nil {
+		return 0, fmt.Errorf("purge deleted todos: %w", err)
+	}
+
+	return result.RowsAffected()
+}
+
+// checkVersioned - проверяет результат запроса с условием "AND version = ?"
+// Если ни одна строка не затронута, выясняет причину: задачи нет или версия устарела
+func (r *PostgresTodoRepository) checkVersioned(ctx context.Context, result sql.Result, id int64) error {
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("todo %d: %w", id, r.missingOrStale(ctx, id))
+	}
+
+	return nil
+}
+
+// missingOrStale - возвращает ErrTodoNotFound, если задачи нет,
+// и ErrVersionConflict, если задача есть, но ее уже изменил кто-то другой
+func (r *PostgresTodoRepository) missingOrStale(ctx context.Context, id int64) error {
+	var exists bool
+	err := r.db.GetContext(ctx, &exists, `SELECT EXISTS(SELECT 1 FROM todos WHERE id = $1 AND deleted_at IS NULL)`, id)
+	if err != nil {
+		return err
+	}
+
+	if !exists {
+		return model.ErrTodoNotFound
+	}
+
+	return model.ErrVersionConflict
+}
+
+// escapeLike - экранирует спецсимволы LIKE, чтобы подстрока искалась буквально
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
+
+// mapError - переводит ошибки PostgreSQL в доменные ошибки
+// Исходная ошибка сохраняется в цепочке (errors.Join), чтобы не потерять детали для логов
+func mapError(err error) error {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) {
+		return err
+	}
+
+	switch pgErr.Code {
+	case "23505": // unique_violation
+		return errors.Join(model.ErrConflict, err)
+	case "23503": // foreign_key_violation
+		return errors.Join(foreignKeyError(pgErr.ConstraintName), err)
+	case "23514": // check_violation
+		return errors.Join(model.ErrValidation, err)
+	}
+
+	return err
+}
+
+// foreignKeyError - доменная ошибка для нарушенного внешнего ключа
+// Ссылки, которые задает клиент (parent_id, blocked_by), - ошибка валидации;
+// отсутствующий пользователь из токена - запрет; пропавшая задача - не найдена
+func foreignKeyError(constraint string) error {
+	switch constraint {
+	case "todos_parent_id_fkey", "todo_dependencies_blocked_by_id_fkey":
+		return model.ErrValidation
+	case "todos_user_id_fkey", "tags_user_id_fkey", "todo_events_actor_id_fkey":
+		return model.ErrForbidden
+	default:
+		return model.ErrTodoNotFound
+	}
+}
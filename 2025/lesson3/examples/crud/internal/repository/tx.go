@@ -0,0 +1,91 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/jmoiron/sqlx"
+
+	"crud-example/internal/logger"
+)
+
+// TxManager - выполняет несколько операций с репозиторием как одну единицу работы
+// fn получает репозиторий, привязанный к транзакции: все его запросы
+// либо фиксируются вместе, либо вместе откатываются, если fn вернула ошибку
+type TxManager interface {
+	WithinTx(ctx context.Context, fn func(repo TodoRepository) error) error
+}
+
+// PostgresTxManager - TxManager поверх sqlx.Tx
+type PostgresTxManager struct {
+	db *sqlx.DB
+}
+
+// NewTxManager - создает менеджер транзакций для PostgreSQL
+func NewTxManager(db *sqlx.DB) *PostgresTxManager {
+	return &PostgresTxManager{db: db}
+}
+
+// WithinTx - открывает транзакцию, вызывает fn и делает COMMIT или ROLLBACK
+// Блокировки, взятые через GetByIDForUpdate, держатся до конца транзакции
+func (m *PostgresTxManager) WithinTx(ctx context.Context, fn func(repo TodoRepository) error) error {
+	tx, err := m.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+
+	// Откатываем транзакцию и при панике внутри fn, чтобы соединение вернулось в пул
+	defer func() {
+		if p := recover(); p != nil {
+			rollback(ctx, tx)
+			panic(p)
+		}
+	}()
+
+	if err := fn(&PostgresTodoRepository{db: tx}); err != nil {
+		rollback(ctx, tx)
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit tx: %w", mapError(err))
+	}
+
+	return nil
+}
+
+// rollback - откатывает транзакцию; ошибку отката только пишем в лог,
+// вызывающему важнее исходная ошибка fn
+func rollback(ctx context.Context, tx *sqlx.Tx) {
+	if err := tx.Rollback(); err != nil {
+		logger.FromContext(ctx).Error("rollback tx", "error", err)
+	}
+}
+
+// InMemoryTxManager - TxManager без отката для InMemoryTodoRepository
+// Каждая операция репозитория в памяти и так атомарна, а WithinTx выполняет fn по одной,
+// поэтому проверки вида "прочитать граф и добавить ребро" (LockDependencies) не пересекаются
+type InMemoryTxManager struct {
+	mu   sync.Mutex
+	repo *InMemoryTodoRepository
+}
+
+// NewInMemoryTxManager - создает менеджер, который просто вызывает fn с repo
+func NewInMemoryTxManager(repo *InMemoryTodoRepository) *InMemoryTxManager {
+	return &InMemoryTxManager{repo: repo}
+}
+
+// WithinTx - вызывает fn с исходным репозиторием, откатывать нечего
+func (m *InMemoryTxManager) WithinTx(ctx context.Context, fn func(repo TodoRepository) error) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return fn(m.repo)
+}
+
+// Проверка на этапе компиляции, что реализации удовлетворяют интерфейсу
+var (
+	_ TxManager = (*PostgresTxManager)(nil)
+	_ TxManager = (*InMemoryTxManager)(nil)
+)
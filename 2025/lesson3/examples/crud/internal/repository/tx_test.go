@@ -0,0 +1,170 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"crud-example/internal/model"
+)
+
+func TestPostgresTxManager(t *testing.T) {
+	db := openTestDB(t)
+	ctx := context.Background()
+	tx := NewTxManager(db)
+	repo := NewTodoRepository(db)
+	userID := createTestUser(t, db)
+
+	t.Run("error rolls back all changes", func(t *testing.T) {
+		todo := mustCreate(t, repo, userID, "Откатить")
+		errStop := errors.New("stop")
+
+		err := tx.WithinTx(ctx, func(txRepo TodoRepository) error {
+			locked, err := txRepo.GetByIDForUpdate(ctx, todo.ID)
+			if err != nil {
+				return err
+			}
+			locked.Title = "Изменено"
+			if err := txRepo.Update(ctx, locked); err != nil {
+				return err
+			}
+			return errStop
+		})
+		if !errors.Is(err, errStop) {
+			t.Fatalf("WithinTx: err = %v, want errStop", err)
+		}
+
+		got, _ := repo.GetByID(ctx, todo.ID)
+		if got.Title != "Откатить" || got.Version != todo.Version {
+			t.Errorf("after rollback: title=%q version=%d", got.Title, got.Version)
+		}
+	})
+
+	t.Run("FOR UPDATE serializes read-modify-write", func(t *testing.T) {
+		todo := mustCreate(t, repo, userID, "Счетчик")
+
+		const workers = 10
+		var wg sync.WaitGroup
+		errs := make(chan error, workers)
+		for i := 0; i < workers; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				errs <- tx.WithinTx(ctx, func(txRepo TodoRepository) error {
+					locked, err := txRepo.GetByIDForUpdate(ctx, todo.ID)
+					if err != nil {
+						return err
+					}
+					locked.Description += "x"
+					return txRepo.Update(ctx, locked)
+				})
+			}()
+		}
+		wg.Wait()
+		close(errs)
+
+		// Без блокировки часть транзакций прочитала бы одну версию и получила ErrVersionConflict
+		for err := range errs {
+			if err != nil {
+				t.Errorf("WithinTx: %v", err)
+			}
+		}
+
+		got, _ := repo.GetByID(ctx, todo.ID)
+		if len(got.Description) != workers || got.Version != todo.Version+workers {
+			t.Errorf("description=%q version=%d, want %d updates", got.Description, got.Version, workers)
+		}
+	})
+
+	t.Run("GetByIDsForUpdate locks rows until commit", func(t *testing.T) {
+		todo := mustCreate(t, repo, userID, "Пакет")
+		locked := make(chan error, 1)
+
+		err := tx.WithinTx(ctx, func(txRepo TodoRepository) error {
+			if _, err := txRepo.GetByIDsForUpdate(ctx, []int64{todo.ID}); err != nil {
+				return err
+			}
+
+			go func() {
+				locked <- tx.WithinTx(ctx, func(other TodoRepository) error {
+					_, err := other.GetByIDForUpdate(ctx, todo.ID)
+					return err
+				})
+			}()
+
+			select {
+			case err := <-locked:
+				t.Errorf("row locked twice before commit: %v", err)
+			case <-time.After(200 * time.Millisecond):
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("WithinTx: %v", err)
+		}
+
+		select {
+		case err := <-locked:
+			if err != nil {
+				t.Errorf("second lock: %v", err)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("row not unlocked after commit")
+		}
+	})
+
+	t.Run("LockDependencies holds until commit", func(t *testing.T) {
+		otherID := createTestUser(t, db)
+		acquired := make(chan error, 1)
+
+		err := tx.WithinTx(ctx, func(txRepo TodoRepository) error {
+			if err := txRepo.LockDependencies(ctx, userID); err != nil {
+				return err
+			}
+
+			// Блокировка другого пользователя не ждет
+			if err := tx.WithinTx(ctx, func(other TodoRepository) error {
+				return other.LockDependencies(ctx, otherID)
+			}); err != nil {
+				return err
+			}
+
+			go func() {
+				acquired <- tx.WithinTx(ctx, func(other TodoRepository) error {
+					return other.LockDependencies(ctx, userID)
+				})
+			}()
+
+			select {
+			case err := <-acquired:
+				t.Errorf("second lock acquired before commit: %v", err)
+			case <-time.After(200 * time.Millisecond):
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("WithinTx: %v", err)
+		}
+
+		select {
+		case err := <-acquired:
+			if err != nil {
+				t.Errorf("second lock: %v", err)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("second lock not acquired after commit")
+		}
+	})
+
+	t.Run("missing todo", func(t *testing.T) {
+		err := tx.WithinTx(ctx, func(txRepo TodoRepository) error {
+			_, err := txRepo.GetByIDForUpdate(ctx, -1)
+			return err
+		})
+		if !errors.Is(err, model.ErrTodoNotFound) {
+			t.Errorf("err = %v, want ErrTodoNotFound", err)
+		}
+	})
+}
@@ -0,0 +1,55 @@
+package response
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// Коды ошибок в поле error.code
+const (
+	CodeBadRequest         = "bad_request"
+	CodeUnauthorized       = "unauthorized"
+	CodeForbidden          = "forbidden"
+	CodeNotFound           = "not_found"
+	CodeMethodNotAllowed   = "method_not_allowed"
+	CodeConflict           = "conflict"
+	CodePreconditionFailed = "precondition_failed"
+	CodePayloadTooLarge    = "payload_too_large"
+	CodeValidation         = "validation_failed"
+	CodeInternal           = "internal_error"
+)
+
+// ErrorResponse - единый формат ошибки API:
+//
+//	{"error": {"code": "not_found", "message": "todo not found"}}
+type ErrorResponse struct {
+	Error ErrorBody `json:"error"`
+}
+
+// ErrorBody - содержимое ошибки
+type ErrorBody struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	Details any    `json:"details,omitempty"` // дополнительные сведения (например, ошибки по элементам)
+}
+
+// JSON - отправляет ответ в формате JSON
+func JSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// Error - отправляет ошибку в едином формате
+func Error(w http.ResponseWriter, status int, code, message string) {
+	JSON(w, status, ErrorResponse{
+		Error: ErrorBody{Code: code, Message: message},
+	})
+}
+
+// ErrorWithDetails - отправляет ошибку с дополнительными сведениями в поле details
+func ErrorWithDetails(w http.ResponseWriter, status int, code, message string, details any) {
+	JSON(w, status, ErrorResponse{
+		Error: ErrorBody{Code: code, Message: message, Details: details},
+	})
+}
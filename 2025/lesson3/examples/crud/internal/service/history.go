@@ -0,0 +1,209 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"crud-example/internal/model"
+	"crud-example/internal/repository"
+)
+
+// GetTodoHistory - история изменений задачи в хронологическом порядке
+func (s *TodoService) GetTodoHistory(ctx context.Context, userID, id int64) ([]*model.TodoEvent, error) {
+	if _, err := s.getOwnedTodo(ctx, userID, id); err != nil {
+		return nil, err
+	}
+
+	return s.repo.ListEvents(ctx, id)
+}
+
+// RevertTodo - возвращает задачу к состоянию, в котором она была в версии toVersion
+// Состояние восстанавливается из истории, сам откат - новая версия (событие reverted).
+// ifVersion - ожидаемая текущая версия задачи (из If-Match) или AnyVersion
+func (s *TodoService) RevertTodo(ctx context.Context, userID, id, ifVersion, toVersion int64) (*model.Todo, error) {
+	if toVersion <= 0 {
+		return nil, model.NewValidationError("version", "must be positive")
+	}
+
+	var todo *model.Todo
+	err := s.withinTx(ctx, func(repo repository.TodoRepository) error {
+		var err error
+		todo, err = getOwnedTodoForUpdate(ctx, repo, userID, id, ifVersion)
+		if err != nil {
+			return err
+		}
+
+		if toVersion >= todo.Version {
+			return model.NewValidationError("version", "must be older than the current version")
+		}
+
+		events, err := repo.ListEvents(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		snapshot, err := replayHistory(events, toVersion)
+		if err != nil {
+			return err
+		}
+
+		priority, ok := model.ParsePriority(snapshot.Priority)
+		if !ok {
+			return fmt.Errorf("revert todo %d: unknown priority %q in history", id, snapshot.Priority)
+		}
+
+		var recurrence *model.Recurrence
+		if snapshot.Recurrence != "" {
+			if recurrence, err = model.ParseRecurrence(snapshot.Recurrence); err != nil {
+				return fmt.Errorf("revert todo %d: recurrence in history: %w", id, err)
+			}
+		}
+
+		before := model.NewTodoSnapshot(todo)
+
+		todo.Title = snapshot.Title
+		todo.Description = snapshot.Description
+		todo.Completed = snapshot.Completed
+		todo.DueAt = snapshot.DueAt
+		todo.Priority = priority
+		todo.Tags = snapshot.Tags
+		todo.Recurrence = recurrence
+
+		// Родитель и зависимости не откатываются: задачи, на которые они ссылались,
+		// могли с тех пор попасть в корзину. Но выполненной задача становится по тем же правилам
+		if !before.Completed && todo.Completed {
+			if err := checkNotBlocked(ctx, repo, todo); err != nil {
+				return err
+			}
+		}
+
+		if err := repo.Update(ctx, todo); err != nil {
+			return err
+		}
+
+		return recordEvent(ctx, repo, userID, model.TodoReverted, todo.ID, todo.Version, &before, model.NewTodoSnapshot(todo))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return todo, nil
+}
+
+// recordEvent - пишет изменение задачи в историю (в той же транзакции, что и изменение)
+// before == nil - задача создана, в After попадает снимок целиком.
+// Иначе в Before и After только изменившиеся поля; если ничего не изменилось, событие не пишется
+func recordEvent(ctx context.Context, repo todoRepository, actorID int64, eventType model.TodoEventType,
+	todoID, version int64, before *model.TodoSnapshot, after model.TodoSnapshot) error {
+	event := &model.TodoEvent{
+		TodoID:  todoID,
+		ActorID: actorID,
+		Type:    eventType,
+		Version: version,
+	}
+
+	var err error
+	if before == nil {
+		event.After, err = json.Marshal(after)
+	} else {
+		event.Before, event.After, err = diffSnapshots(*before, after)
+	}
+	if err != nil {
+		return fmt.Errorf("record %s event for todo %d: %w", eventType, todoID, err)
+	}
+
+	if event.After == nil {
+		return nil
+	}
+
+	return repo.AddEvent(ctx, event)
+}
+
+// diffSnapshots - значения изменившихся полей до и после; nil, nil - изменений нет
+func diffSnapshots(before, after model.TodoSnapshot) (json.RawMessage, json.RawMessage, error) {
+	beforeFields, err := snapshotFields(before)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	afterFields, err := snapshotFields(after)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	changedBefore := make(map[string]json.RawMessage)
+	changedAfter := make(map[string]json.RawMessage)
+	for name, value := range afterFields {
+		if !bytes.Equal(beforeFields[name], value) {
+			changedBefore[name] = beforeFields[name]
+			changedAfter[name] = value
+		}
+	}
+
+	if len(changedAfter) == 0 {
+		return nil, nil, nil
+	}
+
+	// json.Marshal сортирует ключи map, поэтому diff всегда выглядит одинаково
+	beforeJSON, err := json.Marshal(changedBefore)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	afterJSON, err := json.Marshal(changedAfter)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return beforeJSON, afterJSON, nil
+}
+
+// replayHistory - состояние задачи в версии version: After всех событий до нее по порядку
+// поверх друг друга
+func replayHistory(events []*model.TodoEvent, version int64) (model.TodoSnapshot, error) {
+	var snapshot model.TodoSnapshot
+
+	// Задачи, созданные до появления истории, восстановить целиком нельзя
+	if len(events) == 0 || events[0].Type != model.TodoCreated {
+		return snapshot, model.NewValidationError("version", "history of this todo is incomplete")
+	}
+
+	// Версии без событий (изменение без изменившихся полей) совпадают с предыдущей
+	state := make(map[string]json.RawMessage)
+	for _, event := range events {
+		if event.Version > version {
+			break
+		}
+		if err := json.Unmarshal(event.After, &state); err != nil {
+			return snapshot, fmt.Errorf("replay event %d: %w", event.ID, err)
+		}
+	}
+
+	data, err := json.Marshal(state)
+	if err != nil {
+		return snapshot, err
+	}
+
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return snapshot, fmt.Errorf("replay history: %w", err)
+	}
+
+	return snapshot, nil
+}
+
+// snapshotFields - поля снимка в виде JSON значений по именам
+func snapshotFields(snapshot model.TodoSnapshot) (map[string]json.RawMessage, error) {
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		return nil, err
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+
+	return fields, nil
+}
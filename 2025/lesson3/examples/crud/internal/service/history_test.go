@@ -0,0 +1,131 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"crud-example/internal/model"
+)
+
+func TestTodoServiceRevertTodo(t *testing.T) {
+	ctx := context.Background()
+
+	patch := func(t *testing.T, s *TodoService, id int64, p model.TodoPatch) *model.Todo {
+		t.Helper()
+
+		todo, err := s.PatchTodo(ctx, testUserID, id, AnyVersion, p)
+		if err != nil {
+			t.Fatalf("PatchTodo: %v", err)
+		}
+		return todo
+	}
+	ptr := func(s string) *string { return &s }
+
+	t.Run("replays to version", func(t *testing.T) {
+		s := newTestService(t)
+		id := mustCreateTodo(t, s, testUserID, NewTodo{Title: "Купить молоко", Priority: "low", Tags: []string{"дом"}})
+
+		patch(t, s, id, model.TodoPatch{Title: ptr("Купить кефир")}) // v2
+		high := model.PriorityHigh
+		patch(t, s, id, model.TodoPatch{Priority: &high, Tags: &[]string{"магазин"}}) // v3
+		current := patch(t, s, id, model.TodoPatch{Description: ptr("2 литра")})      // v4
+
+		reverted, err := s.RevertTodo(ctx, testUserID, id, current.Version, 2)
+		if err != nil {
+			t.Fatalf("RevertTodo: %v", err)
+		}
+
+		if reverted.Title != "Купить кефир" || reverted.Description != "" ||
+			reverted.Priority != model.PriorityLow || !slices.Equal(reverted.Tags, []string{"дом"}) {
+			t.Errorf("reverted = %+v, want state of version 2", reverted)
+		}
+
+		// Откат - новая версия, поэтому меняется и ETag
+		if reverted.Version != current.Version+1 {
+			t.Errorf("version = %d, want %d", reverted.Version, current.Version+1)
+		}
+
+		events, err := s.GetTodoHistory(ctx, testUserID, id)
+		if err != nil {
+			t.Fatalf("GetTodoHistory: %v", err)
+		}
+		last := events[len(events)-1]
+		if last.Type != model.TodoReverted || last.Version != reverted.Version {
+			t.Errorf("last event = %s v%d, want reverted v%d", last.Type, last.Version, reverted.Version)
+		}
+		assertEventFields(t, last.After, map[string]any{"description": "", "priority": "low"})
+	})
+
+	t.Run("rejects unknown and future versions", func(t *testing.T) {
+		s := newTestService(t)
+		id := mustCreateTodo(t, s, testUserID, NewTodo{Title: "Купить молоко"})
+		current := patch(t, s, id, model.TodoPatch{Title: ptr("Купить кефир")})
+
+		for _, version := range []int64{0, -1, current.Version, current.Version + 1} {
+			_, err := s.RevertTodo(ctx, testUserID, id, AnyVersion, version)
+			assertFieldError(t, err, "version")
+		}
+
+		// If-Match со старой версией
+		if _, err := s.RevertTodo(ctx, testUserID, id, current.Version-1, 1); !errors.Is(err, model.ErrVersionConflict) {
+			t.Errorf("stale If-Match: err = %v, want ErrVersionConflict", err)
+		}
+
+		if _, err := s.RevertTodo(ctx, testUserID+1, id, AnyVersion, 1); !errors.Is(err, model.ErrTodoNotFound) {
+			t.Errorf("another user: err = %v, want ErrTodoNotFound", err)
+		}
+
+		todo, _ := s.GetTodoByID(ctx, testUserID, id)
+		if todo.Version != current.Version || todo.Title != "Купить кефир" {
+			t.Errorf("todo changed by rejected reverts: %+v", todo)
+		}
+	})
+
+	t.Run("unchanged state writes no event", func(t *testing.T) {
+		s := newTestService(t)
+		id := mustCreateTodo(t, s, testUserID, NewTodo{Title: "A"}) // v1
+		patch(t, s, id, model.TodoPatch{Title: ptr("B")})           // v2
+		patch(t, s, id, model.TodoPatch{Title: ptr("A")})           // v3
+
+		// Состояние версии 1 совпадает с текущим: версия растет, но событие не пишется
+		reverted, err := s.RevertTodo(ctx, testUserID, id, AnyVersion, 1)
+		if err != nil {
+			t.Fatalf("RevertTodo: %v", err)
+		}
+		if reverted.Version != 4 {
+			t.Errorf("version = %d, want 4", reverted.Version)
+		}
+
+		events, err := s.GetTodoHistory(ctx, testUserID, id)
+		if err != nil {
+			t.Fatalf("GetTodoHistory: %v", err)
+		}
+		if len(events) != 3 {
+			t.Fatalf("events = %v, want 3 (no event for version 4)", eventTypes(events))
+		}
+
+		// Версия без события восстанавливается как предыдущая
+		patch(t, s, id, model.TodoPatch{Title: ptr("C")}) // v5
+		reverted, err = s.RevertTodo(ctx, testUserID, id, AnyVersion, 4)
+		if err != nil {
+			t.Fatalf("RevertTodo to version without event: %v", err)
+		}
+		if reverted.Title != "A" {
+			t.Errorf("title = %q, want A", reverted.Title)
+		}
+	})
+}
+
+func TestReplayHistoryIncomplete(t *testing.T) {
+	// Задача, созданная до появления истории: первое событие - не created
+	events := []*model.TodoEvent{
+		{Type: model.TodoUpdated, Version: 2, Before: []byte(`{"title":"A"}`), After: []byte(`{"title":"B"}`)},
+	}
+
+	for _, events := range [][]*model.TodoEvent{nil, events} {
+		_, err := replayHistory(events, 1)
+		assertFieldError(t, err, "version")
+	}
+}
@@ -0,0 +1,59 @@
+package service
+
+import (
+	"context"
+
+	"crud-example/internal/model"
+	"crud-example/internal/repository"
+)
+
+// Publisher - получает события истории после COMMIT транзакции (broker.Broker для SSE)
+// Откаченные изменения не публикуются
+type Publisher interface {
+	Publish(event *model.TodoEvent)
+}
+
+// WithPublisher - публиковать изменения задач (события todo_events) в p
+func WithPublisher(p Publisher) Option {
+	return func(s *TodoService) {
+		s.publisher = p
+	}
+}
+
+// withinTx - s.tx.WithinTx, который после COMMIT публикует события, записанные в fn
+// Все изменения задач пишут событие истории через recordEvent, поэтому
+// достаточно перехватить AddEvent репозитория транзакции
+func (s *TodoService) withinTx(ctx context.Context, fn func(repo repository.TodoRepository) error) error {
+	if s.publisher == nil {
+		return s.tx.WithinTx(ctx, fn)
+	}
+
+	var events []*model.TodoEvent
+	err := s.tx.WithinTx(ctx, func(repo repository.TodoRepository) error {
+		return fn(&recordingRepository{TodoRepository: repo, events: &events})
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, event := range events {
+		s.publisher.Publish(event)
+	}
+
+	return nil
+}
+
+// recordingRepository - запоминает события, сохраненные через AddEvent
+type recordingRepository struct {
+	repository.TodoRepository
+	events *[]*model.TodoEvent
+}
+
+func (r *recordingRepository) AddEvent(ctx context.Context, event *model.TodoEvent) error {
+	if err := r.TodoRepository.AddEvent(ctx, event); err != nil {
+		return err
+	}
+
+	*r.events = append(*r.events, event)
+	return nil
+}
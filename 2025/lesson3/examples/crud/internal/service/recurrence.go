@@ -0,0 +1,93 @@
+package service
+
+import (
+	"context"
+	"slices"
+
+	"crud-example/internal/model"
+)
+
+// parseRecurrence - RRULE из запроса; "" - задача не повторяется
+func parseRecurrence(rule string) (*model.Recurrence, error) {
+	if rule == "" {
+		return nil, nil
+	}
+
+	recurrence, err := model.ParseRecurrence(rule)
+	if err != nil {
+		return nil, model.NewValidationError("recurrence", err.Error())
+	}
+
+	return recurrence, nil
+}
+
+// validateRecurrence - повторения считаются от срока, поэтому без срока правило не имеет смысла
+func validateRecurrence(todo *model.Todo) error {
+	if todo.Recurrence != nil && todo.DueAt == nil {
+		return model.NewValidationError("recurrence", "requires due_at")
+	}
+	return nil
+}
+
+// completeTodo - отмечает задачу выполненной и, если она повторяется, создает следующее повторение
+// Правило переходит к новой задаче, а у выполненной убирается: повторная отметка
+// (после снятия) не создаст дубликат. Возвращает следующее повторение или nil
+func completeTodo(ctx context.Context, repo todoRepository, actorID int64, todo *model.Todo) (*model.Todo, error) {
+	before := model.NewTodoSnapshot(todo)
+
+	// Уже выполненная задача остается как есть: повторение создается только при переходе
+	var rule *model.Recurrence
+	if !todo.Completed {
+		rule, todo.Recurrence = todo.Recurrence, nil
+	}
+	todo.Completed = true
+
+	if err := repo.Update(ctx, todo); err != nil {
+		return nil, err
+	}
+
+	if err := recordEvent(ctx, repo, actorID, model.TodoCompleted, todo.ID, todo.Version, &before, model.NewTodoSnapshot(todo)); err != nil {
+		return nil, err
+	}
+
+	if rule == nil {
+		return nil, nil
+	}
+
+	return scheduleNext(ctx, repo, actorID, todo, rule)
+}
+
+// scheduleNext - создает следующее повторение задачи todo по правилу rule
+// Срок считается от срока todo, а не от момента выполнения: расписание не сдвигается,
+// даже если задачу выполнили с опозданием. nil, nil - серия закончилась (COUNT или UNTIL)
+func scheduleNext(ctx context.Context, repo todoRepository, actorID int64, todo *model.Todo, rule *model.Recurrence) (*model.Todo, error) {
+	if todo.DueAt == nil {
+		return nil, nil
+	}
+
+	dueAt, nextRule, ok := rule.Next(*todo.DueAt)
+	if !ok {
+		return nil, nil
+	}
+
+	next := &model.Todo{
+		UserID:      todo.UserID,
+		Title:       todo.Title,
+		Description: todo.Description,
+		DueAt:       &dueAt,
+		Priority:    todo.Priority,
+		Tags:        slices.Clone(todo.Tags),
+		Recurrence:  nextRule,
+		ParentID:    todo.ParentID, // зависимости не переносятся: они относились к этому повторению
+	}
+
+	if _, err := repo.Create(ctx, next); err != nil {
+		return nil, err
+	}
+
+	if err := recordEvent(ctx, repo, actorID, model.TodoCreated, next.ID, next.Version, nil, model.NewTodoSnapshot(next)); err != nil {
+		return nil, err
+	}
+
+	return next, nil
+}
@@ -0,0 +1,168 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"slices"
+	"testing"
+	"time"
+
+	"crud-example/internal/model"
+)
+
+func TestTodoServiceCompleteRecurring(t *testing.T) {
+	ctx := context.Background()
+	at := func(s string) *time.Time {
+		due, err := time.Parse("2006-01-02 15:04", s)
+		if err != nil {
+			panic(err)
+		}
+		return &due
+	}
+
+	// completeSeries - выполняет повторения одно за другим, пока серия не закончится,
+	// и возвращает сроки созданных повторений
+	completeSeries := func(t *testing.T, s *TodoService, id int64) []string {
+		var dues []string
+		for range 10 {
+			next, err := s.CompleteTodo(ctx, testUserID, id, AnyVersion)
+			if err != nil {
+				t.Fatalf("CompleteTodo(%d): %v", id, err)
+			}
+			if next == nil {
+				return dues
+			}
+			dues = append(dues, next.DueAt.Format("2006-01-02 15:04"))
+			id = next.ID
+		}
+		t.Fatalf("series did not end: %v", dues)
+		return nil
+	}
+
+	t.Run("count runs out", func(t *testing.T) {
+		s := newTestService(t)
+		id := mustCreateTodo(t, s, testUserID, NewTodo{
+			Title: "Зарядка", DueAt: at("2025-01-06 09:00"), Recurrence: "FREQ=DAILY;COUNT=3",
+		})
+
+		got := completeSeries(t, s, id)
+		want := []string{"2025-01-07 09:00", "2025-01-08 09:00"}
+		if !slices.Equal(got, want) {
+			t.Errorf("occurrences = %v, want %v", got, want)
+		}
+	})
+
+	t.Run("until runs out", func(t *testing.T) {
+		s := newTestService(t)
+		id := mustCreateTodo(t, s, testUserID, NewTodo{
+			Title: "Отчет", DueAt: at("2025-01-01 10:00"), Recurrence: "FREQ=WEEKLY;UNTIL=20250110",
+		})
+
+		got := completeSeries(t, s, id)
+		want := []string{"2025-01-08 10:00"}
+		if !slices.Equal(got, want) {
+			t.Errorf("occurrences = %v, want %v", got, want)
+		}
+	})
+
+	t.Run("due in the past", func(t *testing.T) {
+		s := newTestService(t)
+		due := time.Now().UTC().Truncate(time.Minute).AddDate(0, 0, -30)
+		id := mustCreateTodo(t, s, testUserID, NewTodo{Title: "Полить цветы", DueAt: &due, Recurrence: "FREQ=WEEKLY"})
+
+		next, err := s.CompleteTodo(ctx, testUserID, id, AnyVersion)
+		if err != nil {
+			t.Fatalf("CompleteTodo: %v", err)
+		}
+
+		// Срок считается от срока задачи, а не от момента выполнения: просроченное повторение
+		// остается просроченным, расписание не сдвигается
+		if want := due.AddDate(0, 0, 7); next == nil || !next.DueAt.Equal(want) {
+			t.Errorf("next = %+v, want due %s", next, want)
+		}
+	})
+
+	t.Run("missing due_at", func(t *testing.T) {
+		s := newTestService(t)
+
+		_, err := s.CreateTodo(ctx, testUserID, NewTodo{Title: "Без срока", Recurrence: "FREQ=DAILY"})
+		assertFieldError(t, err, "recurrence")
+
+		id := mustCreateTodo(t, s, testUserID, NewTodo{Title: "Со сроком", DueAt: at("2025-01-06 09:00"), Recurrence: "FREQ=DAILY"})
+		_, err = s.PatchTodo(ctx, testUserID, id, AnyVersion, model.TodoPatch{ClearDueAt: true})
+		assertFieldError(t, err, "recurrence")
+	})
+
+	t.Run("history and repeated completion", func(t *testing.T) {
+		s := newTestService(t)
+		id := mustCreateTodo(t, s, testUserID, NewTodo{
+			Title: "Зарядка", DueAt: at("2025-01-06 09:00"), Recurrence: "FREQ=DAILY;COUNT=3",
+		})
+
+		next, err := s.CompleteTodo(ctx, testUserID, id, AnyVersion)
+		if err != nil || next == nil {
+			t.Fatalf("CompleteTodo = %v, %v; want next occurrence", next, err)
+		}
+
+		// Правило переходит к следующему повторению, у выполненной задачи оно убирается
+		events, err := s.GetTodoHistory(ctx, testUserID, id)
+		if err != nil {
+			t.Fatalf("GetTodoHistory: %v", err)
+		}
+		if len(events) != 2 || events[1].Type != model.TodoCompleted {
+			t.Fatalf("events = %v, want created and completed", eventTypes(events))
+		}
+		assertEventFields(t, events[1].Before, map[string]any{"completed": false, "recurrence": "FREQ=DAILY;COUNT=3"})
+		assertEventFields(t, events[1].After, map[string]any{"completed": true, "recurrence": ""})
+
+		events, err = s.GetTodoHistory(ctx, testUserID, next.ID)
+		if err != nil {
+			t.Fatalf("GetTodoHistory(next): %v", err)
+		}
+		if len(events) != 1 || events[0].Type != model.TodoCreated || events[0].Before != nil {
+			t.Fatalf("next events = %v, want created", eventTypes(events))
+		}
+		assertEventFields(t, events[0].After, map[string]any{
+			"completed": false, "recurrence": "FREQ=DAILY;COUNT=2", "due_at": "2025-01-07T09:00:00Z",
+		})
+
+		// Снятая и снова выполненная задача не создает второе повторение
+		reopen := false
+		if _, err := s.PatchTodo(ctx, testUserID, id, AnyVersion, model.TodoPatch{Completed: &reopen}); err != nil {
+			t.Fatalf("PatchTodo completed=false: %v", err)
+		}
+		if again, err := s.CompleteTodo(ctx, testUserID, id, AnyVersion); err != nil || again != nil {
+			t.Errorf("CompleteTodo again = %v, %v; want nil, nil", again, err)
+		}
+
+		todos, err := s.GetUserTodos(ctx, testUserID)
+		if err != nil || len(todos) != 2 {
+			t.Errorf("GetUserTodos = %d todos, %v; want 2", len(todos), err)
+		}
+	})
+}
+
+func eventTypes(events []*model.TodoEvent) []model.TodoEventType {
+	types := make([]model.TodoEventType, 0, len(events))
+	for _, event := range events {
+		types = append(types, event.Type)
+	}
+	return types
+}
+
+// assertEventFields - в JSON события есть поля want с такими значениями
+func assertEventFields(t *testing.T, raw json.RawMessage, want map[string]any) {
+	t.Helper()
+
+	var fields map[string]any
+	if err := json.Unmarshal(raw, &fields); err != nil {
+		t.Fatalf("unmarshal event %s: %v", raw, err)
+	}
+
+	for name, value := range want {
+		got, ok := fields[name]
+		if !ok || got != value {
+			t.Errorf("event %s: %s = %v, want %v", raw, name, got, value)
+		}
+	}
+}
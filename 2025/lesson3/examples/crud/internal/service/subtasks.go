@@ -0,0 +1,219 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"crud-example/internal/model"
+)
+
+// MaxBlockersPerTodo - сколько задач может блокировать одну задачу
+const MaxBlockersPerTodo = 50
+
+// GetTodoTree - задача с подзадачами всех уровней и процентом выполнения каждой
+func (s *TodoService) GetTodoTree(ctx context.Context, userID, id int64) (*model.TodoTree, error) {
+	todo, err := s.getOwnedTodo(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	// Подзадачи принадлежат владельцу родителя: это проверяет checkParent
+	descendants, err := s.repo.ListSubtree(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return model.NewTodoTree(todo, descendants), nil
+}
+
+// normalizeBlockers - проверяет ID блокирующих задач, убирает повторы и сортирует
+func normalizeBlockers(ids []int64) ([]int64, error) {
+	if len(ids) > MaxBlockersPerTodo {
+		return nil, model.NewValidationError("blocked_by", fmt.Sprintf("too many todos (max %d)", MaxBlockersPerTodo))
+	}
+
+	normalized := make([]int64, 0, len(ids))
+	for _, id := range ids {
+		if id <= 0 {
+			return nil, model.NewValidationError("blocked_by", "ids must be positive")
+		}
+		if !slices.Contains(normalized, id) {
+			normalized = append(normalized, id)
+		}
+	}
+
+	slices.Sort(normalized)
+	return normalized, nil
+}
+
+// checkRelations - проверяет родителя и зависимости задачи перед сохранением
+// before - состояние до изменения (nil для новой задачи). Проверяются только новые связи:
+// задача, которая блокирует эту и с тех пор попала в корзину, не мешает менять остальные поля
+// Для существующей задачи вызывается после LockDependencies владельца: проверка читает граф целиком
+func checkRelations(ctx context.Context, repo todoRepository, todo *model.Todo, before *model.TodoSnapshot) error {
+	parentChanged := todo.ParentID != nil &&
+		(before == nil || before.ParentID == nil || *before.ParentID != *todo.ParentID)
+	if parentChanged {
+		if err := checkParent(ctx, repo, todo); err != nil {
+			return err
+		}
+	}
+
+	var added []int64
+	for _, id := range todo.BlockedBy {
+		if before == nil || !slices.Contains(before.BlockedBy, id) {
+			added = append(added, id)
+		}
+	}
+	if len(added) == 0 {
+		return nil
+	}
+
+	return checkBlockers(ctx, repo, todo, added)
+}
+
+// checkParent - родитель существует, принадлежит тому же пользователю и не является
+// подзадачей самой задачи (иначе в дереве появится цикл)
+func checkParent(ctx context.Context, repo todoRepository, todo *model.Todo) error {
+	parentID := *todo.ParentID
+	if parentID == todo.ID {
+		return model.NewValidationError("parent_id", "todo cannot be its own parent")
+	}
+
+	parent, err := repo.GetByID(ctx, parentID)
+	if errors.Is(err, model.ErrTodoNotFound) || (err == nil && parent.UserID != todo.UserID) {
+		return model.NewValidationError("parent_id", fmt.Sprintf("todo %d not found", parentID))
+	}
+	if err != nil {
+		return err
+	}
+
+	// У новой задачи еще нет подзадач
+	if todo.ID == 0 {
+		return nil
+	}
+
+	descendants, err := repo.ListSubtree(ctx, todo.ID)
+	if err != nil {
+		return err
+	}
+	for _, descendant := range descendants {
+		if descendant.ID == parentID {
+			return model.NewValidationError("parent_id", fmt.Sprintf("todo %d is a subtask of this todo", parentID))
+		}
+	}
+
+	return nil
+}
+
+// checkBlockers - новые блокирующие задачи существуют, принадлежат тому же пользователю
+// и сами (напрямую или через другие задачи) не ждут эту задачу
+func checkBlockers(ctx context.Context, repo todoRepository, todo *model.Todo, added []int64) error {
+	if slices.Contains(added, todo.ID) {
+		return model.NewValidationError("blocked_by", "todo cannot block itself")
+	}
+
+	blockers, err := repo.GetByIDs(ctx, added)
+	if err != nil {
+		return err
+	}
+
+	owned := make(map[int64]bool, len(blockers))
+	for _, blocker := range blockers {
+		owned[blocker.ID] = blocker.UserID == todo.UserID
+	}
+	for _, id := range added {
+		if !owned[id] {
+			return model.NewValidationError("blocked_by", fmt.Sprintf("todo %d not found", id))
+		}
+	}
+
+	// На новую задачу еще никто не ссылается, цикла быть не может
+	if todo.ID == 0 {
+		return nil
+	}
+
+	graph, err := repo.ListDependencies(ctx, todo.UserID)
+	if err != nil {
+		return err
+	}
+
+	for _, id := range added {
+		if dependsOn(graph, id, todo.ID) {
+			return model.NewValidationError("blocked_by", fmt.Sprintf("todo %d already depends on this todo", id))
+		}
+	}
+
+	return nil
+}
+
+// dependsOn - задача from ждет задачу target напрямую или через цепочку зависимостей (обход в глубину)
+func dependsOn(graph map[int64][]int64, from, target int64) bool {
+	visited := make(map[int64]bool)
+	stack := []int64{from}
+
+	for len(stack) > 0 {
+		id := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		if id == target {
+			return true
+		}
+		if visited[id] {
+			continue
+		}
+		visited[id] = true
+
+		stack = append(stack, graph[id]...)
+	}
+
+	return false
+}
+
+// checkNotBlocked - задачи todos можно отметить выполненными: все их блокирующие задачи
+// уже выполнены, удалены или отмечаются выполненными вместе с ними (в том же пакете)
+func checkNotBlocked(ctx context.Context, repo todoRepository, todos ...*model.Todo) error {
+	completing := make(map[int64]bool, len(todos))
+	for _, todo := range todos {
+		completing[todo.ID] = true
+	}
+
+	var ids []int64
+	for _, todo := range todos {
+		for _, id := range todo.BlockedBy {
+			if !completing[id] && !slices.Contains(ids, id) {
+				ids = append(ids, id)
+			}
+		}
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	// GetByIDs не возвращает задачи из корзины: удаленная задача больше не блокирует
+	blockers, err := repo.GetByIDs(ctx, ids)
+	if err != nil {
+		return err
+	}
+
+	open := make(map[int64]bool, len(blockers))
+	for _, blocker := range blockers {
+		open[blocker.ID] = !blocker.Completed
+	}
+
+	for _, todo := range todos {
+		var blockedBy []int64
+		for _, id := range todo.BlockedBy {
+			if open[id] && !completing[id] {
+				blockedBy = append(blockedBy, id)
+			}
+		}
+		if len(blockedBy) > 0 {
+			return &model.BlockedTodoError{ID: todo.ID, BlockedBy: blockedBy}
+		}
+	}
+
+	return nil
+}
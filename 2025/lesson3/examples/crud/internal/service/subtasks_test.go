@@ -0,0 +1,247 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+
+	"crud-example/internal/model"
+)
+
+const testUserID int64 = 1
+
+func TestTodoServiceRejectsCycles(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("parent", func(t *testing.T) {
+		s := newTestService(t)
+		root := mustCreateTodo(t, s, testUserID, NewTodo{Title: "Корень"})
+		child := mustCreateTodo(t, s, testUserID, NewTodo{Title: "Подзадача", ParentID: &root})
+		grandchild := mustCreateTodo(t, s, testUserID, NewTodo{Title: "Подподзадача", ParentID: &child})
+
+		for _, parentID := range []int64{root, grandchild} {
+			_, err := s.PatchTodo(ctx, testUserID, root, AnyVersion, model.TodoPatch{ParentID: &parentID})
+			assertFieldError(t, err, "parent_id")
+		}
+	})
+
+	t.Run("blockers", func(t *testing.T) {
+		s := newTestService(t)
+		a := mustCreateTodo(t, s, testUserID, NewTodo{Title: "A"})
+		b := mustCreateTodo(t, s, testUserID, NewTodo{Title: "B", BlockedBy: []int64{a}})
+		c := mustCreateTodo(t, s, testUserID, NewTodo{Title: "C", BlockedBy: []int64{b}})
+
+		for _, blockedBy := range [][]int64{{a}, {b}, {c}} {
+			_, err := s.PatchTodo(ctx, testUserID, a, AnyVersion, model.TodoPatch{BlockedBy: &blockedBy})
+			assertFieldError(t, err, "blocked_by")
+		}
+
+		// Без цикла: C и так ждет A через B
+		blockedBy := []int64{a, b}
+		if _, err := s.PatchTodo(ctx, testUserID, c, AnyVersion, model.TodoPatch{BlockedBy: &blockedBy}); err != nil {
+			t.Errorf("PatchTodo without cycle: %v", err)
+		}
+	})
+
+	t.Run("concurrent opposite blockers", func(t *testing.T) {
+		s := newTestService(t)
+		a := mustCreateTodo(t, s, testUserID, NewTodo{Title: "A"})
+		b := mustCreateTodo(t, s, testUserID, NewTodo{Title: "B"})
+
+		var wg sync.WaitGroup
+		errs := make([]error, 2)
+		for i, pair := range [][2]int64{{a, b}, {b, a}} {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				blockedBy := []int64{pair[1]}
+				_, errs[i] = s.PatchTodo(ctx, testUserID, pair[0], AnyVersion, model.TodoPatch{BlockedBy: &blockedBy})
+			}()
+		}
+		wg.Wait()
+
+		// Ровно одна из встречных зависимостей сохраняется
+		if (errs[0] == nil) == (errs[1] == nil) {
+			t.Fatalf("errors = %v, want exactly one cycle error", errs)
+		}
+		for _, err := range errs {
+			if err != nil {
+				assertFieldError(t, err, "blocked_by")
+			}
+		}
+	})
+}
+
+func assertFieldError(t *testing.T, err error, field string) {
+	t.Helper()
+
+	var validationErr *model.ValidationError
+	if !errors.As(err, &validationErr) || validationErr.Field != field {
+		t.Errorf("err = %v, want validation error on %s", err, field)
+	}
+}
+
+func TestTodoServiceBlockedCompletion(t *testing.T) {
+	ctx := context.Background()
+
+	// setup - задача blocked ждет невыполненную задачу blocker
+	setup := func(t *testing.T) (s *TodoService, blocker, blocked int64) {
+		s = newTestService(t)
+		blocker = mustCreateTodo(t, s, testUserID, NewTodo{Title: "Собрать вещи"})
+		blocked = mustCreateTodo(t, s, testUserID, NewTodo{Title: "Уехать", BlockedBy: []int64{blocker}})
+		return s, blocker, blocked
+	}
+
+	t.Run("single", func(t *testing.T) {
+		s, blocker, blocked := setup(t)
+
+		_, err := s.CompleteTodo(ctx, testUserID, blocked, AnyVersion)
+		assertBlocked(t, err, blocked, blocker)
+		assertCompleted(t, s, blocked, false)
+
+		if _, err := s.CompleteTodo(ctx, testUserID, blocker, AnyVersion); err != nil {
+			t.Fatalf("CompleteTodo(blocker): %v", err)
+		}
+		if _, err := s.CompleteTodo(ctx, testUserID, blocked, AnyVersion); err != nil {
+			t.Errorf("CompleteTodo after blocker: %v", err)
+		}
+	})
+
+	t.Run("batch", func(t *testing.T) {
+		s, blocker, blocked := setup(t)
+		other := mustCreateTodo(t, s, testUserID, NewTodo{Title: "Полить цветы"})
+
+		// Пакет выполняется целиком или не выполняется совсем
+		_, err := s.CompleteTodos(ctx, testUserID, []int64{other, blocked})
+		assertBlocked(t, err, blocked, blocker)
+		assertCompleted(t, s, other, false)
+
+		// Блокирующая задача в том же пакете не мешает
+		n, err := s.CompleteTodos(ctx, testUserID, []int64{blocked, blocker})
+		if err != nil || n != 2 {
+			t.Fatalf("CompleteTodos with blocker = %d, %v; want 2, nil", n, err)
+		}
+		assertCompleted(t, s, blocked, true)
+	})
+
+	t.Run("patch", func(t *testing.T) {
+		s, blocker, blocked := setup(t)
+
+		completed := true
+		_, err := s.PatchTodo(ctx, testUserID, blocked, AnyVersion, model.TodoPatch{Completed: &completed})
+		assertBlocked(t, err, blocked, blocker)
+
+		// Остальные поля блокированной задачи менять можно
+		title := "Уехать в отпуск"
+		if _, err := s.PatchTodo(ctx, testUserID, blocked, AnyVersion, model.TodoPatch{Title: &title}); err != nil {
+			t.Errorf("PatchTodo title: %v", err)
+		}
+	})
+
+	t.Run("revert", func(t *testing.T) {
+		s, blocker, blocked := setup(t)
+
+		if _, err := s.CompleteTodo(ctx, testUserID, blocker, AnyVersion); err != nil {
+			t.Fatalf("CompleteTodo(blocker): %v", err)
+		}
+		if _, err := s.CompleteTodo(ctx, testUserID, blocked, AnyVersion); err != nil {
+			t.Fatalf("CompleteTodo(blocked): %v", err)
+		}
+		done, err := s.GetTodoByID(ctx, testUserID, blocked)
+		if err != nil {
+			t.Fatalf("GetTodoByID: %v", err)
+		}
+
+		// Обе задачи снова открыты: откат к выполненной версии упирается в блокирующую задачу
+		reopen := false
+		for _, id := range []int64{blocked, blocker} {
+			if _, err := s.PatchTodo(ctx, testUserID, id, AnyVersion, model.TodoPatch{Completed: &reopen}); err != nil {
+				t.Fatalf("PatchTodo(%d) completed=false: %v", id, err)
+			}
+		}
+
+		_, err = s.RevertTodo(ctx, testUserID, blocked, AnyVersion, done.Version)
+		assertBlocked(t, err, blocked, blocker)
+		assertCompleted(t, s, blocked, false)
+	})
+
+	t.Run("deleted blocker", func(t *testing.T) {
+		s, blocker, blocked := setup(t)
+
+		if err := s.DeleteTodo(ctx, testUserID, blocker, AnyVersion); err != nil {
+			t.Fatalf("DeleteTodo: %v", err)
+		}
+		if _, err := s.CompleteTodo(ctx, testUserID, blocked, AnyVersion); err != nil {
+			t.Errorf("CompleteTodo with deleted blocker: %v", err)
+		}
+	})
+}
+
+func TestTodoServiceGetTodoTree(t *testing.T) {
+	ctx := context.Background()
+	s := newTestService(t)
+
+	root := mustCreateTodo(t, s, testUserID, NewTodo{Title: "Переезд"})
+	packed := mustCreateTodo(t, s, testUserID, NewTodo{Title: "Упаковать", ParentID: &root})
+	moved := mustCreateTodo(t, s, testUserID, NewTodo{Title: "Перевезти", ParentID: &root})
+	mustCreateTodo(t, s, testUserID, NewTodo{Title: "Найти машину", ParentID: &moved})
+	loaded := mustCreateTodo(t, s, testUserID, NewTodo{Title: "Погрузить", ParentID: &moved})
+	mustCreateTodo(t, s, testUserID, NewTodo{Title: "Разгрузить", ParentID: &moved})
+
+	if _, err := s.CompleteTodos(ctx, testUserID, []int64{packed, loaded}); err != nil {
+		t.Fatalf("CompleteTodos: %v", err)
+	}
+
+	tree, err := s.GetTodoTree(ctx, testUserID, root)
+	if err != nil {
+		t.Fatalf("GetTodoTree: %v", err)
+	}
+
+	// Перевезти: 1 из 3 подзадач -> 33; Переезд: (100 + 33) / 2 -> 66
+	progress := map[int64]int{}
+	var walk func(node *model.TodoTree)
+	walk = func(node *model.TodoTree) {
+		progress[node.Todo.ID] = node.Progress
+		for _, subtask := range node.Subtasks {
+			walk(subtask)
+		}
+	}
+	walk(tree)
+
+	want := map[int64]int{root: 66, packed: 100, moved: 33, loaded: 100}
+	for id, p := range want {
+		if progress[id] != p {
+			t.Errorf("progress of %d = %d, want %d", id, progress[id], p)
+		}
+	}
+	if len(progress) != 6 {
+		t.Errorf("tree has %d todos, want 6", len(progress))
+	}
+
+	if _, err := s.GetTodoTree(ctx, testUserID+1, root); !errors.Is(err, model.ErrTodoNotFound) {
+		t.Errorf("GetTodoTree of another user: err = %v, want ErrTodoNotFound", err)
+	}
+}
+
+func assertBlocked(t *testing.T, err error, id int64, blockedBy ...int64) {
+	t.Helper()
+
+	var blockedErr *model.BlockedTodoError
+	if !errors.As(err, &blockedErr) || blockedErr.ID != id || !slices.Equal(blockedErr.BlockedBy, blockedBy) {
+		t.Errorf("err = %v, want todo %d blocked by %v", err, id, blockedBy)
+	}
+}
+
+func assertCompleted(t *testing.T, s *TodoService, id int64, want bool) {
+	t.Helper()
+
+	todo, err := s.GetTodoByID(context.Background(), testUserID, id)
+	if err != nil {
+		t.Fatalf("GetTodoByID(%d): %v", id, err)
+	}
+	if todo.Completed != want {
+		t.Errorf("todo %d completed = %v, want %v", id, todo.Completed, want)
+	}
+}
@@ -3,64 +3,148 @@ package service
 import (
 	"context"
 	"errors"
+	"fmt"
+	"slices"
+	"strings"
 	"time"
+	"unicode/utf8"
 
 	"crud-example/internal/model"
+	"crud-example/internal/repository"
 )
 
+// Размер страницы для ListTodos
+const (
+	DefaultPageSize = 20
+	MaxPageSize     = 100
+)
+
+// DefaultMaxBatchSize - ограничение размера пакетных запросов по умолчанию
+const DefaultMaxBatchSize = 100
+
+// DefaultMaxImportSize - ограничение количества задач в одном импорте по умолчанию
+const DefaultMaxImportSize = 5000
+
+// MaxSearchQueryLength - максимальная длина поискового запроса в символах
+const MaxSearchQueryLength = 200
+
+// Ограничения полей задачи (в символах, а не байтах)
+const (
+	MaxTitleLength       = 255 // VARCHAR(255) в таблице todos
+	MaxDescriptionLength = 5000
+)
+
+// Ограничения тегов задачи
+const (
+	MaxTagsPerTodo = 20
+	MaxTagLength   = 50 // VARCHAR(50) в таблице tags
+)
+
+// AnyVersion - значение ifVersion, при котором версия задачи не проверяется
+// (клиент не прислал If-Match)
+const AnyVersion int64 = 0
+
 type todoRepository interface {
 	Create(ctx context.Context, todo *model.Todo) (int64, error)
 	GetByID(ctx context.Context, id int64) (*model.Todo, error)
+	GetByIDForUpdate(ctx context.Context, id int64) (*model.Todo, error)
 	GetAllByUserID(ctx context.Context, userID int64) ([]*model.Todo, error)
+	List(ctx context.Context, userID int64, filter model.TodoFilter) ([]*model.Todo, error)
+	Search(ctx context.Context, userID int64, query string, limit int) ([]*model.TodoSearchHit, error)
 	Update(ctx context.Context, todo *model.Todo) error
-	Delete(ctx context.Context, id int64) error
+	Delete(ctx context.Context, id, version int64) error
+	BatchInsert(ctx context.Context, todos []*model.Todo) error
+	GetByIDs(ctx context.Context, ids []int64) ([]*model.Todo, error)
+	GetByIDsForUpdate(ctx context.Context, ids []int64) ([]*model.Todo, error)
+	CompleteByIDs(ctx context.Context, userID int64, ids []int64) (int64, error)
+	DeleteByIDs(ctx context.Context, userID int64, ids []int64) (int64, error)
+	Restore(ctx context.Context, userID, id int64) (*model.Todo, error)
+	PurgeDeleted(ctx context.Context, olderThan time.Duration) (int64, error)
+	AddEvent(ctx context.Context, event *model.TodoEvent) error
+	ListEvents(ctx context.Context, todoID int64) ([]*model.TodoEvent, error)
+	ListSubtree(ctx context.Context, id int64) ([]*model.Todo, error)
+	ListDependencies(ctx context.Context, userID int64) (map[int64][]int64, error)
+	LockDependencies(ctx context.Context, userID int64) error
+}
+
+// txManager - выполняет fn в транзакции (repository.PostgresTxManager или InMemoryTxManager)
+type txManager interface {
+	WithinTx(ctx context.Context, fn func(repo repository.TodoRepository) error) error
 }
 
 // TodoService - бизнес-логика для задач
 type TodoService struct {
-	repo todoRepository
+	repo          todoRepository
+	tx            txManager
+	maxBatchSize  int
+	maxImportSize int
+	publisher     Publisher // nil - изменения никуда не публикуются
 }
 
-// NewTodoService - создает новый сервис
-func NewTodoService(repo todoRepository) *TodoService {
-	return &TodoService{repo: repo}
+// Option - необязательная настройка TodoService
+type Option func(*TodoService)
+
+// WithMaxBatchSize - максимальное количество элементов в пакетном запросе
+func WithMaxBatchSize(n int) Option {
+	return func(s *TodoService) {
+		s.maxBatchSize = n
+	}
 }
 
-// CreateTodo - создает новую задачу с валидацией
-func (s *TodoService) CreateTodo(ctx context.Context, userID int64, title, description string) (*model.Todo, error) {
-	// Бизнес-логика: валидация
-	if title == "" {
-		return nil, errors.New("title cannot be empty")
+// WithMaxImportSize - максимальное количество задач в одном импорте
+func WithMaxImportSize(n int) Option {
+	return func(s *TodoService) {
+		s.maxImportSize = n
 	}
+}
 
-	if len(title) > 255 {
-		return nil, errors.New("title too long (max 255 characters)")
+// NewTodoService - создает новый сервис
+// repo используется для одиночных запросов, tx - для операций "прочитать и изменить"
+func NewTodoService(repo todoRepository, tx txManager, opts ...Option) *TodoService {
+	s := &TodoService{
+		repo:          repo,
+		tx:            tx,
+		maxBatchSize:  DefaultMaxBatchSize,
+		maxImportSize: DefaultMaxImportSize,
 	}
 
-	// Создаем модель
-	todo := &model.Todo{
-		UserID:      userID,
-		Title:       title,
-		Description: description,
-		Completed:   false,
+	for _, opt := range opts {
+		opt(s)
 	}
 
-	// Сохраняем через репозиторий
-	id, err := s.repo.Create(ctx, todo)
+	return s
+}
+
+// CreateTodo - создает новую задачу с валидацией
+func (s *TodoService) CreateTodo(ctx context.Context, userID int64, input NewTodo) (*model.Todo, error) {
+	// Бизнес-логика: валидация и создание модели
+	todo, err := newTodoModel(userID, input)
+	if err != nil {
+		return nil, err
+	}
+
+	// Сохраняем через репозиторий: задача, ее теги, зависимости и запись в истории - в одной транзакции
+	err = s.withinTx(ctx, func(repo repository.TodoRepository) error {
+		if err := checkRelations(ctx, repo, todo, nil); err != nil {
+			return err
+		}
+
+		if _, err := repo.Create(ctx, todo); err != nil {
+			return err
+		}
+
+		return recordEvent(ctx, repo, userID, model.TodoCreated, todo.ID, todo.Version, nil, model.NewTodoSnapshot(todo))
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	todo.ID = id
 	return todo, nil
 }
 
-// GetTodoByID - получает задачу по ID
-// Важно: данная функция скорее антипрактик (и другие функции где просто вызов другой функции). В ней ничего не происходит кроме вызова
-// другой такой же функции. Лучше всего удалить эту функцию и в хэндлере вызывать напрямую репозиторий
-// смысл будет такой же.
-func (s *TodoService) GetTodoByID(ctx context.Context, id int64) (*model.Todo, error) {
-	return s.repo.GetByID(ctx, id)
+// GetTodoByID - получает задачу по ID, если она принадлежит пользователю
+func (s *TodoService) GetTodoByID(ctx context.Context, userID, id int64) (*model.Todo, error) {
+	return s.getOwnedTodo(ctx, userID, id)
 }
 
 // GetUserTodos - получает все задачи пользователя
@@ -68,48 +152,793 @@ func (s *TodoService) GetUserTodos(ctx context.Context, userID int64) ([]*model.
 	return s.repo.GetAllByUserID(ctx, userID)
 }
 
-// CompleteTodo - отмечает задачу как выполненную
-func (s *TodoService) CompleteTodo(ctx context.Context, todoID int64) error {
-	// Получаем задачу
-	todo, err := s.repo.GetByID(ctx, todoID)
+// ListTodos - получает страницу задач пользователя с фильтрами
+func (s *TodoService) ListTodos(ctx context.Context, userID int64, filter model.TodoFilter) (*model.TodoPage, error) {
+	switch {
+	case filter.Limit == 0:
+		filter.Limit = DefaultPageSize
+	case filter.Limit < 0:
+		return nil, model.NewValidationError("limit", "must be positive")
+	case filter.Limit > MaxPageSize:
+		filter.Limit = MaxPageSize
+	}
+
+	if err := validateFilter(&filter); err != nil {
+		return nil, err
+	}
+
+	// Запрашиваем на одну запись больше: если она пришла, значит есть следующая страница
+	pageSize := filter.Limit
+	filter.Limit++
+
+	todos, err := s.repo.List(ctx, userID, filter)
 	if err != nil {
+		return nil, err
+	}
+
+	page := &model.TodoPage{Todos: todos}
+	if len(todos) > pageSize {
+		page.Todos = todos[:pageSize]
+		page.NextCursor = model.NewTodoCursor(page.Todos[pageSize-1], filter.Sort)
+	}
+
+	return page, nil
+}
+
+// CompleteTodo - отмечает задачу как выполненную
+// Если задачу блокируют невыполненные задачи, возвращает BlockedTodoError.
+// Для повторяющейся задачи в той же транзакции создается следующее повторение,
+// оно и возвращается; иначе (и если серия закончилась) - nil.
+// ifVersion - ожидаемая версия задачи (из If-Match) или AnyVersion
+func (s *TodoService) CompleteTodo(ctx context.Context, userID, todoID, ifVersion int64) (*model.Todo, error) {
+	var next *model.Todo
+
+	// Чтение и запись в одной транзакции: строка заблокирована до COMMIT,
+	// поэтому параллельный запрос не изменит задачу между ними
+	err := s.withinTx(ctx, func(repo repository.TodoRepository) error {
+		// Получаем задачу (с проверкой владельца и версии)
+		todo, err := getOwnedTodoForUpdate(ctx, repo, userID, todoID, ifVersion)
+		if err != nil {
+			return err
+		}
+
+		if !todo.Completed {
+			if err := checkNotBlocked(ctx, repo, todo); err != nil {
+				return err
+			}
+		}
+
+		// Меняем статус, сохраняем и планируем следующее повторение
+		next, err = completeTodo(ctx, repo, userID, todo)
 		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return next, nil
+}
+
+// UpdateTodo - полностью заменяет изменяемые поля задачи (PUT)
+// Непереданные необязательные поля сбрасываются: срок убирается, приоритет становится normal
+func (s *TodoService) UpdateTodo(ctx context.Context, userID, id, ifVersion int64, input NewTodo, completed bool) (*model.Todo, error) {
+	priority, err := parsePriority(input.Priority)
+	if err != nil {
+		return nil, err
 	}
 
-	// Меняем статус
-	todo.Completed = true
-	todo.UpdatedAt = time.Now()
+	recurrence, err := parseRecurrence(input.Recurrence)
+	if err != nil {
+		return nil, err
+	}
+
+	tags := input.Tags
+	if tags == nil {
+		tags = []string{}
+	}
 
-	// Сохраняем
-	return s.repo.Update(ctx, todo)
+	blockedBy := input.BlockedBy
+	if blockedBy == nil {
+		blockedBy = []int64{}
+	}
+
+	return s.PatchTodo(ctx, userID, id, ifVersion, model.TodoPatch{
+		Title:           &input.Title,
+		Description:     &input.Description,
+		Completed:       &completed,
+		DueAt:           input.DueAt,
+		ClearDueAt:      input.DueAt == nil,
+		Priority:        &priority,
+		Tags:            &tags,
+		Recurrence:      recurrence,
+		ClearRecurrence: recurrence == nil,
+		ParentID:        input.ParentID,
+		ClearParent:     input.ParentID == nil,
+		BlockedBy:       &blockedBy,
+	})
 }
 
-// UpdateTodo - обновляет задачу
-func (s *TodoService) UpdateTodo(ctx context.Context, id int64, title, description string) error {
+// PatchTodo - меняет только переданные поля задачи (PATCH)
+// Если патч отмечает задачу выполненной, проверяются блокирующие задачи, а для повторяющейся
+// задачи создается следующее повторение, как в CompleteTodo
+func (s *TodoService) PatchTodo(ctx context.Context, userID, id, ifVersion int64, patch model.TodoPatch) (*model.Todo, error) {
 	// Валидация
-	if title == "" {
-		return errors.New("title cannot be empty")
+	if patch.Title != nil {
+		if err := validateTitle(*patch.Title); err != nil {
+			return nil, err
+		}
+	}
+
+	if patch.Description != nil {
+		if err := validateDescription(*patch.Description); err != nil {
+			return nil, err
+		}
+	}
+
+	if patch.Priority != nil && !patch.Priority.Valid() {
+		return nil, model.NewValidationError("priority", "unknown priority")
+	}
+
+	var tags []string
+	if patch.Tags != nil {
+		var err error
+		if tags, err = normalizeTags(*patch.Tags); err != nil {
+			return nil, err
+		}
+	}
+
+	var blockedBy []int64
+	if patch.BlockedBy != nil {
+		var err error
+		if blockedBy, err = normalizeBlockers(*patch.BlockedBy); err != nil {
+			return nil, err
+		}
 	}
 
-	if len(title) > 255 {
-		return errors.New("title too long")
+	var todo *model.Todo
+	err := s.withinTx(ctx, func(repo repository.TodoRepository) error {
+		// Связи пользователя меняются по одной транзакции, иначе две встречные зависимости
+		// пройдут проверку циклов одновременно. Блокировка берется до блокировки строки:
+		// вставка ссылки на другую задачу ждет ее FOR UPDATE, и порядок наоборот дал бы deadlock
+		if patch.ParentID != nil || patch.BlockedBy != nil {
+			if err := repo.LockDependencies(ctx, userID); err != nil {
+				return err
+			}
+		}
+
+		// Получаем задачу (с проверкой владельца и версии) и блокируем ее
+		var err error
+		todo, err = getOwnedTodoForUpdate(ctx, repo, userID, id, ifVersion)
+		if err != nil {
+			return err
+		}
+
+		before := model.NewTodoSnapshot(todo)
+
+		// Обновляем поля
+		if patch.Title != nil {
+			todo.Title = *patch.Title
+		}
+		if patch.Description != nil {
+			todo.Description = *patch.Description
+		}
+		if patch.Completed != nil {
+			todo.Completed = *patch.Completed
+		}
+		if patch.DueAt != nil {
+			dueAt := patch.DueAt.UTC()
+			todo.DueAt = &dueAt
+		}
+		if patch.ClearDueAt {
+			todo.DueAt = nil
+		}
+		if patch.Priority != nil {
+			todo.Priority = *patch.Priority
+		}
+		if patch.Tags != nil {
+			todo.Tags = tags
+		}
+		if patch.Recurrence != nil {
+			todo.Recurrence = patch.Recurrence
+		}
+		if patch.ClearRecurrence {
+			todo.Recurrence = nil
+		}
+		if patch.ParentID != nil {
+			todo.ParentID = patch.ParentID
+		}
+		if patch.ClearParent {
+			todo.ParentID = nil
+		}
+		if patch.BlockedBy != nil {
+			todo.BlockedBy = blockedBy
+		}
+
+		if err := validateRecurrence(todo); err != nil {
+			return err
+		}
+
+		if err := checkRelations(ctx, repo, todo, &before); err != nil {
+			return err
+		}
+
+		if !before.Completed && todo.Completed {
+			if err := checkNotBlocked(ctx, repo, todo); err != nil {
+				return err
+			}
+		}
+
+		// Правило переходит к следующему повторению (см. completeTodo)
+		var rule *model.Recurrence
+		if !before.Completed && todo.Completed && todo.Recurrence != nil {
+			rule, todo.Recurrence = todo.Recurrence, nil
+		}
+
+		// Сохраняем (repo.Update проставит todo.Version и todo.UpdatedAt).
+		// Проверка версии в Update остается страховкой для in-memory реализации
+		if err := repo.Update(ctx, todo); err != nil {
+			return err
+		}
+
+		if err := recordEvent(ctx, repo, userID, model.TodoUpdated, todo.ID, todo.Version, &before, model.NewTodoSnapshot(todo)); err != nil {
+			return err
+		}
+
+		if rule == nil {
+			return nil
+		}
+
+		_, err = scheduleNext(ctx, repo, userID, todo, rule)
+		return err
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	// Получаем задачу
+	return todo, nil
+}
+
+// DeleteTodo - удаляет задачу
+func (s *TodoService) DeleteTodo(ctx context.Context, userID, id, ifVersion int64) error {
+	return s.withinTx(ctx, func(repo repository.TodoRepository) error {
+		todo, err := getOwnedTodoForUpdate(ctx, repo, userID, id, ifVersion)
+		if err != nil {
+			return err
+		}
+
+		if err := repo.Delete(ctx, todo.ID, todo.Version); err != nil {
+			return err
+		}
+
+		return recordDeleted(ctx, repo, userID, todo)
+	})
+}
+
+// SearchTodos - полнотекстовый поиск по задачам пользователя, самые релевантные первыми
+// limit == 0 - DefaultPageSize, больше MaxPageSize не возвращается
+func (s *TodoService) SearchTodos(ctx context.Context, userID int64, query string, limit int) ([]*model.TodoSearchHit, error) {
+	query = strings.TrimSpace(query)
+	switch {
+	case query == "":
+		return nil, model.NewValidationError("q", "is required")
+	case utf8.RuneCountInString(query) > MaxSearchQueryLength:
+		return nil, model.NewValidationError("q", fmt.Sprintf("too long (max %d characters)", MaxSearchQueryLength))
+	}
+
+	switch {
+	case limit == 0:
+		limit = DefaultPageSize
+	case limit < 0:
+		return nil, model.NewValidationError("limit", "must be positive")
+	case limit > MaxPageSize:
+		limit = MaxPageSize
+	}
+
+	return s.repo.Search(ctx, userID, query, limit)
+}
+
+// ListOverdue - невыполненные задачи пользователя, срок которых уже прошел
+// По умолчанию сортируются по сроку: самые давно просроченные первыми
+func (s *TodoService) ListOverdue(ctx context.Context, userID int64, filter model.TodoFilter) (*model.TodoPage, error) {
+	notCompleted := false
+	filter.Completed = &notCompleted
+	filter.DueTo = time.Now().UTC()
+
+	if filter.Sort == "" {
+		filter.Sort = model.SortDueAt
+	}
+
+	return s.ListTodos(ctx, userID, filter)
+}
+
+// ListTrash - получает страницу удаленных задач пользователя (корзина)
+// Фильтры и пагинация такие же, как у ListTodos
+func (s *TodoService) ListTrash(ctx context.Context, userID int64, filter model.TodoFilter) (*model.TodoPage, error) {
+	filter.Deleted = true
+	return s.ListTodos(ctx, userID, filter)
+}
+
+// RestoreTodo - возвращает задачу из корзины
+func (s *TodoService) RestoreTodo(ctx context.Context, userID, id int64) (*model.Todo, error) {
+	var todo *model.Todo
+	err := s.withinTx(ctx, func(repo repository.TodoRepository) error {
+		var err error
+		if todo, err = repo.Restore(ctx, userID, id); err != nil {
+			return err
+		}
+
+		after := model.NewTodoSnapshot(todo)
+		before := after
+		before.Deleted = true
+
+		return recordEvent(ctx, repo, userID, model.TodoRestored, todo.ID, todo.Version, &before, after)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return todo, nil
+}
+
+// PurgeTrash - окончательно удаляет задачи всех пользователей, лежащие в корзине дольше retention
+func (s *TodoService) PurgeTrash(ctx context.Context, retention time.Duration) (int64, error) {
+	if retention <= 0 {
+		return 0, model.NewValidationError("retention", "must be positive")
+	}
+
+	return s.repo.PurgeDeleted(ctx, retention)
+}
+
+// NewTodo - данные для создания (или полной замены) задачи
+type NewTodo struct {
+	Title       string
+	Description string
+	DueAt       *time.Time // nil - без срока
+	Priority    string     // "low", "normal", "high", "urgent"; "" - normal
+	Tags        []string
+	Recurrence  string // RRULE (FREQ=WEEKLY;BYDAY=MO); "" - не повторяется, требует DueAt
+	ParentID    *int64 // nil - задача верхнего уровня
+	BlockedBy   []int64
+}
+
+// CreateTodos - создает несколько задач: либо все, либо ни одной
+// Ошибки валидации собираются по всем элементам сразу (BatchValidationError)
+func (s *TodoService) CreateTodos(ctx context.Context, userID int64, items []NewTodo) ([]*model.Todo, error) {
+	if err := s.validateBatchSize("items", len(items)); err != nil {
+		return nil, err
+	}
+
+	var itemErrs []model.ItemError
+	todos := make([]*model.Todo, 0, len(items))
+
+	for i, item := range items {
+		todo, err := newTodoModel(userID, item)
+		if err != nil {
+			var validationErr *model.ValidationError
+			if errors.As(err, &validationErr) {
+				itemErrs = append(itemErrs, model.ItemError{
+					Index:   i,
+					Field:   validationErr.Field,
+					Message: validationErr.Message,
+				})
+				continue
+			}
+			return nil, err
+		}
+
+		todos = append(todos, todo)
+	}
+
+	if len(itemErrs) > 0 {
+		return nil, &model.BatchValidationError{Items: itemErrs}
+	}
+
+	err := s.withinTx(ctx, func(repo repository.TodoRepository) error {
+		// Связи проверяются по базе, поэтому уже в транзакции
+		for i, todo := range todos {
+			err := checkRelations(ctx, repo, todo, nil)
+			var validationErr *model.ValidationError
+			if errors.As(err, &validationErr) {
+				itemErrs = append(itemErrs, model.ItemError{
+					Index:   i,
+					Field:   validationErr.Field,
+					Message: validationErr.Message,
+				})
+				continue
+			}
+			if err != nil {
+				return err
+			}
+		}
+
+		if len(itemErrs) > 0 {
+			return &model.BatchValidationError{Items: itemErrs}
+		}
+
+		if err := repo.BatchInsert(ctx, todos); err != nil {
+			return err
+		}
+
+		for _, todo := range todos {
+			err := recordEvent(ctx, repo, userID, model.TodoCreated, todo.ID, todo.Version, nil, model.NewTodoSnapshot(todo))
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return todos, nil
+}
+
+// CompleteTodos - отмечает выполненными несколько задач пользователя
+// Если хотя бы одна задача не найдена или чужая, ничего не меняется (MissingTodosError),
+// как и если хотя бы одну блокирует невыполненная задача не из этого пакета (BlockedTodoError).
+// Для повторяющихся задач создаются следующие повторения, как в CompleteTodo
+func (s *TodoService) CompleteTodos(ctx context.Context, userID int64, ids []int64) (int64, error) {
+	var n int64
+	err := s.withinTx(ctx, func(repo repository.TodoRepository) error {
+		todos, err := s.checkOwnedIDs(ctx, repo, userID, ids)
+		if err != nil {
+			return err
+		}
+
+		var completing []*model.Todo
+		for _, todo := range todos {
+			if !todo.Completed {
+				completing = append(completing, todo)
+			}
+		}
+		if err := checkNotBlocked(ctx, repo, completing...); err != nil {
+			return err
+		}
+
+		// Повторяющиеся задачи отмечаются по одной: вместе с ними создаются следующие повторения.
+		// Остальные - одним запросом
+		var plain []*model.Todo
+		for _, todo := range todos {
+			if todo.Completed || todo.Recurrence == nil {
+				plain = append(plain, todo)
+				continue
+			}
+			if _, err := completeTodo(ctx, repo, userID, todo); err != nil {
+				return err
+			}
+			n++
+		}
+
+		if len(plain) == 0 {
+			return nil
+		}
+
+		completed, err := repo.CompleteByIDs(ctx, userID, todoIDs(plain))
+		if err != nil {
+			return err
+		}
+		n += completed
+
+		// CompleteByIDs меняет (и переводит на следующую версию) только невыполненные задачи
+		for _, todo := range plain {
+			if todo.Completed {
+				continue
+			}
+
+			before := model.NewTodoSnapshot(todo)
+			after := before
+			after.Completed = true
+
+			err := recordEvent(ctx, repo, userID, model.TodoCompleted, todo.ID, todo.Version+1, &before, after)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return n, nil
+}
+
+// DeleteTodos - удаляет несколько задач пользователя
+// Если хотя бы одна задача не найдена или чужая, ничего не удаляется (MissingTodosError)
+func (s *TodoService) DeleteTodos(ctx context.Context, userID int64, ids []int64) (int64, error) {
+	var n int64
+	err := s.withinTx(ctx, func(repo repository.TodoRepository) error {
+		todos, err := s.checkOwnedIDs(ctx, repo, userID, ids)
+		if err != nil {
+			return err
+		}
+
+		if n, err = repo.DeleteByIDs(ctx, userID, todoIDs(todos)); err != nil {
+			return err
+		}
+
+		for _, todo := range todos {
+			if err := recordDeleted(ctx, repo, userID, todo); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return n, nil
+}
+
+// recordDeleted - пишет в историю удаление задачи; todo - состояние до удаления,
+// Delete и DeleteByIDs переводят задачу на следующую версию
+func recordDeleted(ctx context.Context, repo todoRepository, actorID int64, todo *model.Todo) error {
+	before := model.NewTodoSnapshot(todo)
+	after := before
+	after.Deleted = true
+
+	return recordEvent(ctx, repo, actorID, model.TodoDeleted, todo.ID, todo.Version+1, &before, after)
+}
+
+// todoIDs - ID задач в том же порядке
+func todoIDs(todos []*model.Todo) []int64 {
+	ids := make([]int64, 0, len(todos))
+	for _, todo := range todos {
+		ids = append(ids, todo.ID)
+	}
+	return ids
+}
+
+// checkOwnedIDs - убирает дубликаты и проверяет, что все задачи существуют и принадлежат пользователю
+// Возвращает найденные задачи в порядке ids: их состояние до изменения нужно для истории.
+// Строки заблокированы до конца транзакции, иначе параллельный PATCH между чтением и
+// CompleteByIDs/DeleteByIDs сделал бы снимок "до" и версию в событии устаревшими
+func (s *TodoService) checkOwnedIDs(ctx context.Context, repo todoRepository, userID int64, ids []int64) ([]*model.Todo, error) {
+	if err := s.validateBatchSize("ids", len(ids)); err != nil {
+		return nil, err
+	}
+
+	unique := make([]int64, 0, len(ids))
+	seen := make(map[int64]bool, len(ids))
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+
+	todos, err := repo.GetByIDsForUpdate(ctx, unique)
+	if err != nil {
+		return nil, err
+	}
+
+	owned := make(map[int64]*model.Todo, len(todos))
+	for _, todo := range todos {
+		// Чужие задачи считаем ненайденными, как и в getOwnedTodo
+		if todo.UserID == userID {
+			owned[todo.ID] = todo
+		}
+	}
+
+	var missing []int64
+	result := make([]*model.Todo, 0, len(unique))
+	for _, id := range unique {
+		todo, ok := owned[id]
+		if !ok {
+			missing = append(missing, id)
+			continue
+		}
+		result = append(result, todo)
+	}
+
+	if len(missing) > 0 {
+		return nil, &model.MissingTodosError{IDs: missing}
+	}
+
+	return result, nil
+}
+
+// validateFilter - проверяет диапазоны дат, сортировку и курсор фильтра
+// Пустая сортировка заменяется на SortCreatedAt
+func validateFilter(filter *model.TodoFilter) error {
+	if !filter.CreatedFrom.IsZero() && !filter.CreatedTo.IsZero() && !filter.CreatedFrom.Before(filter.CreatedTo) {
+		return model.NewValidationError("created_to", "must be after created_from")
+	}
+
+	if !filter.DueFrom.IsZero() && !filter.DueTo.IsZero() && !filter.DueFrom.Before(filter.DueTo) {
+		return model.NewValidationError("due_to", "must be after due_from")
+	}
+
+	if filter.Sort == "" {
+		filter.Sort = model.SortCreatedAt
+	}
+	if !filter.Sort.Valid() {
+		return model.NewValidationError("sort", "unknown sort order")
+	}
+
+	// Курсор хранит ключи своей сортировки: с другой сортировкой он бессмысленен.
+	// Курсоры без sort выданы до появления сортировок и относятся к created_at
+	if filter.After != nil {
+		cursorSort := filter.After.Sort
+		if cursorSort == "" {
+			cursorSort = model.SortCreatedAt
+		}
+		if cursorSort != filter.Sort {
+			return model.NewValidationError("cursor", "was issued for a different sort order")
+		}
+	}
+
+	return nil
+}
+
+// validateBatchSize - пакет не пустой и не больше maxBatchSize
+func (s *TodoService) validateBatchSize(field string, n int) error {
+	if n == 0 {
+		return model.NewValidationError(field, "cannot be empty")
+	}
+
+	if n > s.maxBatchSize {
+		return model.NewValidationError(field, fmt.Sprintf("too many items (max %d)", s.maxBatchSize))
+	}
+
+	return nil
+}
+
+// getOwnedTodo - получает задачу и проверяет, что она принадлежит пользователю
+func (s *TodoService) getOwnedTodo(ctx context.Context, userID, id int64) (*model.Todo, error) {
 	todo, err := s.repo.GetByID(ctx, id)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	// Обновляем поля
-	todo.Title = title
-	todo.Description = description
+	// Для чужих задач намеренно возвращаем "не найдено", а не "доступ запрещен",
+	// чтобы не раскрывать сам факт существования задачи
+	if todo.UserID != userID {
+		return nil, model.ErrTodoNotFound
+	}
 
-	// Сохраняем
-	return s.repo.Update(ctx, todo)
+	return todo, nil
 }
 
-// DeleteTodo - удаляет задачу
-func (s *TodoService) DeleteTodo(ctx context.Context, id int64) error {
-	return s.repo.Delete(ctx, id)
+// getOwnedTodoForUpdate - получает задачу с блокировкой строки, проверяет владельца
+// и сверяет версию с ifVersion. Вызывается внутри WithinTx
+func getOwnedTodoForUpdate(ctx context.Context, repo todoRepository, userID, id, ifVersion int64) (*model.Todo, error) {
+	todo, err := repo.GetByIDForUpdate(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	// Как и в getOwnedTodo: чужая задача - "не найдено"
+	if todo.UserID != userID {
+		return nil, model.ErrTodoNotFound
+	}
+
+	if ifVersion != AnyVersion && todo.Version != ifVersion {
+		return nil, model.ErrVersionConflict
+	}
+
+	return todo, nil
+}
+
+// newTodoModel - проверяет данные новой задачи и создает модель
+func newTodoModel(userID int64, input NewTodo) (*model.Todo, error) {
+	if err := validateTitle(input.Title); err != nil {
+		return nil, err
+	}
+
+	if err := validateDescription(input.Description); err != nil {
+		return nil, err
+	}
+
+	priority, err := parsePriority(input.Priority)
+	if err != nil {
+		return nil, err
+	}
+
+	tags, err := normalizeTags(input.Tags)
+	if err != nil {
+		return nil, err
+	}
+
+	blockedBy, err := normalizeBlockers(input.BlockedBy)
+	if err != nil {
+		return nil, err
+	}
+
+	recurrence, err := parseRecurrence(input.Recurrence)
+	if err != nil {
+		return nil, err
+	}
+
+	todo := &model.Todo{
+		UserID:      userID,
+		Title:       input.Title,
+		Description: input.Description,
+		Priority:    priority,
+		Tags:        tags,
+		Recurrence:  recurrence,
+		ParentID:    input.ParentID,
+		BlockedBy:   blockedBy,
+	}
+
+	// Колонка due_at хранит время без часового пояса, поэтому приводим к UTC
+	if input.DueAt != nil {
+		dueAt := input.DueAt.UTC()
+		todo.DueAt = &dueAt
+	}
+
+	if err := validateRecurrence(todo); err != nil {
+		return nil, err
+	}
+
+	return todo, nil
+}
+
+// parsePriority - "high" → model.PriorityHigh, "" → model.PriorityNormal
+func parsePriority(name string) (model.Priority, error) {
+	if name == "" {
+		return model.PriorityNormal, nil
+	}
+
+	priority, ok := model.ParsePriority(name)
+	if !ok {
+		return 0, model.NewValidationError("priority",
+			"must be one of: "+strings.Join(model.PriorityNames(), ", "))
+	}
+
+	return priority, nil
+}
+
+// normalizeTags - обрезает пробелы, приводит к нижнему регистру, убирает повторы и сортирует
+func normalizeTags(tags []string) ([]string, error) {
+	if len(tags) > MaxTagsPerTodo {
+		return nil, model.NewValidationError("tags", fmt.Sprintf("too many tags (max %d)", MaxTagsPerTodo))
+	}
+
+	normalized := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+
+		if tag == "" {
+			return nil, model.NewValidationError("tags", "tag cannot be empty")
+		}
+		if utf8.RuneCountInString(tag) > MaxTagLength {
+			return nil, model.NewValidationError("tags", fmt.Sprintf("tag too long (max %d characters)", MaxTagLength))
+		}
+
+		if !slices.Contains(normalized, tag) {
+			normalized = append(normalized, tag)
+		}
+	}
+
+	slices.Sort(normalized)
+	return normalized, nil
+}
+
+// validateTitle - проверяет заголовок задачи
+func validateTitle(title string) error {
+	if title == "" {
+		return model.NewValidationError("title", "cannot be empty")
+	}
+
+	// VARCHAR(255) ограничивает символы, а не байты: "задача" - 6 символов, но 12 байт
+	if utf8.RuneCountInString(title) > MaxTitleLength {
+		return model.NewValidationError("title", fmt.Sprintf("too long (max %d characters)", MaxTitleLength))
+	}
+
+	return nil
+}
+
+// validateDescription - проверяет описание задачи
+func validateDescription(description string) error {
+	if utf8.RuneCountInString(description) > MaxDescriptionLength {
+		return model.NewValidationError("description", fmt.Sprintf("too long (max %d characters)", MaxDescriptionLength))
+	}
+
+	return nil
 }
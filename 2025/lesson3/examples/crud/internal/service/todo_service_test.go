@@ -0,0 +1,114 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"crud-example/internal/model"
+	"crud-example/internal/repository"
+)
+
+// newTestService - сервис поверх репозитория в памяти
+func newTestService(t *testing.T, opts ...Option) *TodoService {
+	t.Helper()
+
+	repo := repository.NewInMemoryTodoRepository()
+	return NewTodoService(repo, repository.NewInMemoryTxManager(repo), opts...)
+}
+
+func mustCreateTodo(t *testing.T, s *TodoService, userID int64, input NewTodo) int64 {
+	t.Helper()
+
+	todo, err := s.CreateTodo(context.Background(), userID, input)
+	if err != nil {
+		t.Fatalf("CreateTodo(%q): %v", input.Title, err)
+	}
+
+	return todo.ID
+}
+
+// TestTodoServiceBatchEvents - пакетные операции пишут в историю версию, которую получила задача
+func TestTodoServiceBatchEvents(t *testing.T) {
+	ctx := context.Background()
+	s := newTestService(t)
+
+	edited := mustCreateTodo(t, s, testUserID, NewTodo{Title: "Изменена"})
+	plain := mustCreateTodo(t, s, testUserID, NewTodo{Title: "Без изменений"})
+	title := "Изменена дважды"
+	if _, err := s.PatchTodo(ctx, testUserID, edited, AnyVersion, model.TodoPatch{Title: &title}); err != nil {
+		t.Fatalf("PatchTodo: %v", err)
+	}
+
+	if _, err := s.CompleteTodos(ctx, testUserID, []int64{edited, plain}); err != nil {
+		t.Fatalf("CompleteTodos: %v", err)
+	}
+	if _, err := s.DeleteTodos(ctx, testUserID, []int64{plain, edited}); err != nil {
+		t.Fatalf("DeleteTodos: %v", err)
+	}
+
+	// История удаленной задачи недоступна, пока задача в корзине
+	for _, id := range []int64{edited, plain} {
+		if _, err := s.RestoreTodo(ctx, testUserID, id); err != nil {
+			t.Fatalf("RestoreTodo(%d): %v", id, err)
+		}
+	}
+
+	for _, id := range []int64{edited, plain} {
+		events, err := s.GetTodoHistory(ctx, testUserID, id)
+		if err != nil {
+			t.Fatalf("GetTodoHistory(%d): %v", id, err)
+		}
+
+		for i, event := range events {
+			if event.Version != int64(i+1) {
+				t.Errorf("todo %d: event %s has version %d, want %d", id, event.Type, event.Version, i+1)
+			}
+		}
+		if types := eventTypes(events); !slices.Contains(types, model.TodoCompleted) || !slices.Contains(types, model.TodoDeleted) {
+			t.Errorf("todo %d: events = %v, want completed and deleted", id, types)
+		}
+	}
+}
+
+// TestTodoServiceHidesForeignTodos - чужая задача для пользователя не существует (404, а не 403)
+func TestTodoServiceHidesForeignTodos(t *testing.T) {
+	ctx := context.Background()
+	s := newTestService(t)
+
+	const alice, bob int64 = 1, 2
+	id := mustCreateTodo(t, s, alice, NewTodo{Title: "Задача Алисы"})
+	title := "Задача Боба"
+
+	operations := map[string]func() error{
+		"get": func() error {
+			_, err := s.GetTodoByID(ctx, bob, id)
+			return err
+		},
+		"patch": func() error {
+			_, err := s.PatchTodo(ctx, bob, id, AnyVersion, model.TodoPatch{Title: &title})
+			return err
+		},
+		"delete": func() error {
+			return s.DeleteTodo(ctx, bob, id, AnyVersion)
+		},
+		"complete": func() error {
+			_, err := s.CompleteTodo(ctx, bob, id, AnyVersion)
+			return err
+		},
+	}
+
+	for name, op := range operations {
+		t.Run(name, func(t *testing.T) {
+			if err := op(); !errors.Is(err, model.ErrTodoNotFound) {
+				t.Errorf("err = %v, want ErrTodoNotFound", err)
+			}
+		})
+	}
+
+	todo, err := s.GetTodoByID(ctx, alice, id)
+	if err != nil || todo.Title != "Задача Алисы" || todo.Completed || todo.Version != 1 {
+		t.Errorf("alice's todo = %+v, %v; want unchanged", todo, err)
+	}
+}
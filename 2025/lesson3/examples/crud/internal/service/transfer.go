@@ -0,0 +1,118 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"crud-example/internal/model"
+	"crud-example/internal/repository"
+)
+
+// exportPageSize - сколько задач ExportTodos читает из репозитория за один запрос
+const exportPageSize = 500
+
+// importChunkSize - сколько задач вставляется одним BatchInsert
+// Не больше repository.MaxBatchInsertSize: он считается из лимита Postgres на параметры
+// запроса и количества колонок, поэтому новая колонка в BatchInsert не выведет за лимит
+const importChunkSize = min(1000, repository.MaxBatchInsertSize)
+
+// ImportTodo - задача из импортируемого файла
+type ImportTodo struct {
+	NewTodo
+	Completed bool
+	Line      int // строка файла, на нее ссылаются ошибки валидации
+}
+
+// ExportTodos - передает в fn задачи пользователя, подходящие под filter, по одной
+// Задачи читаются страницами по exportPageSize, поэтому память не зависит от их количества.
+// filter.Limit и filter.After не учитываются: экспортируется весь список
+func (s *TodoService) ExportTodos(ctx context.Context, userID int64, filter model.TodoFilter, fn func(todo *model.Todo) error) error {
+	filter.After = nil
+	if err := validateFilter(&filter); err != nil {
+		return err
+	}
+
+	filter.Limit = exportPageSize
+
+	for {
+		todos, err := s.repo.List(ctx, userID, filter)
+		if err != nil {
+			return err
+		}
+
+		for _, todo := range todos {
+			if err := fn(todo); err != nil {
+				return err
+			}
+		}
+
+		if len(todos) < exportPageSize {
+			return nil
+		}
+
+		filter.After = model.NewTodoCursor(todos[len(todos)-1], filter.Sort)
+	}
+}
+
+// ImportTodos - создает задачи из импортируемого файла: либо все, либо ни одной
+// Ошибки валидации собираются по всем задачам сразу (ImportValidationError с номерами строк).
+// Задачи вставляются через BatchInsert частями по importChunkSize в одной транзакции
+func (s *TodoService) ImportTodos(ctx context.Context, userID int64, items []ImportTodo) ([]*model.Todo, error) {
+	if len(items) == 0 {
+		return nil, model.NewValidationError("file", "no todos to import")
+	}
+
+	if len(items) > s.maxImportSize {
+		return nil, model.NewValidationError("file", fmt.Sprintf("too many todos (max %d)", s.maxImportSize))
+	}
+
+	var lineErrs []model.LineError
+	todos := make([]*model.Todo, 0, len(items))
+
+	for _, item := range items {
+		todo, err := newTodoModel(userID, item.NewTodo)
+		if err != nil {
+			var validationErr *model.ValidationError
+			if errors.As(err, &validationErr) {
+				lineErrs = append(lineErrs, model.LineError{
+					Line:    item.Line,
+					Field:   validationErr.Field,
+					Message: validationErr.Message,
+				})
+				continue
+			}
+			return nil, err
+		}
+
+		todo.Completed = item.Completed
+		todos = append(todos, todo)
+	}
+
+	if len(lineErrs) > 0 {
+		return nil, &model.ImportValidationError{Lines: lineErrs}
+	}
+
+	err := s.withinTx(ctx, func(repo repository.TodoRepository) error {
+		for start := 0; start < len(todos); start += importChunkSize {
+			chunk := todos[start:min(start+importChunkSize, len(todos))]
+			if err := repo.BatchInsert(ctx, chunk); err != nil {
+				return err
+			}
+		}
+
+		for _, todo := range todos {
+			err := recordEvent(ctx, repo, userID, model.TodoCreated, todo.ID, todo.Version, nil, model.NewTodoSnapshot(todo))
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return todos, nil
+}
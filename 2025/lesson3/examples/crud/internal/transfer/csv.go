@@ -0,0 +1,201 @@
+package transfer
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"crud-example/internal/model"
+)
+
+// csvColumns - колонки CSV при экспорте
+// При импорте обязательна только title, порядок колонок любой;
+// id, created_at и updated_at допускаются, но не используются
+var csvColumns = []string{"id", "title", "description", "completed", "due_at", "priority", "tags", "recurrence", "created_at", "updated_at"}
+
+// csvTagSeparator - разделитель тегов внутри ячейки tags: "work;urgent"
+const csvTagSeparator = ";"
+
+// csvEncoder - CSV с заголовком, время в RFC3339 (UTC)
+type csvEncoder struct {
+	w *csv.Writer
+}
+
+func newCSVEncoder(w io.Writer) (*csvEncoder, error) {
+	e := &csvEncoder{w: csv.NewWriter(w)}
+	if err := e.w.Write(csvColumns); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+func (e *csvEncoder) Encode(todo *model.Todo) error {
+	rec := NewRecord(todo)
+
+	dueAt := ""
+	if rec.DueAt != nil {
+		dueAt = rec.DueAt.Format(time.RFC3339)
+	}
+
+	return e.w.Write([]string{
+		strconv.FormatInt(rec.ID, 10),
+		rec.Title,
+		rec.Description,
+		strconv.FormatBool(rec.Completed),
+		dueAt,
+		rec.Priority,
+		strings.Join(rec.Tags, csvTagSeparator),
+		rec.Recurrence,
+		rec.CreatedAt.Format(time.RFC3339),
+		rec.UpdatedAt.Format(time.RFC3339),
+	})
+}
+
+func (e *csvEncoder) Close() error {
+	e.w.Flush()
+	return e.w.Error()
+}
+
+// decodeCSV - первая строка - заголовок с именами колонок
+func decodeCSV(r io.Reader) ([]Record, []model.LineError, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = 0 // все строки - столько же полей, сколько в заголовке
+
+	header, err := cr.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, []model.LineError{{Line: 1, Message: "file is empty"}}, nil
+	}
+	if lineErr, ok := csvLineError(err); ok {
+		return nil, []model.LineError{lineErr}, nil
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	columns, lineErrs := csvHeader(header)
+	if len(lineErrs) > 0 {
+		return nil, lineErrs, nil
+	}
+
+	var records []Record
+	for {
+		row, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if lineErr, ok := csvLineError(err); ok {
+			lineErrs = append(lineErrs, lineErr)
+			// После ошибки в кавычках границы следующих строк не определить
+			if !errors.Is(err, csv.ErrFieldCount) {
+				break
+			}
+			continue
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+
+		line, _ := cr.FieldPos(0)
+		rec, errs := csvRecord(row, columns, line)
+		if len(errs) > 0 {
+			lineErrs = append(lineErrs, errs...)
+			continue
+		}
+		records = append(records, rec)
+	}
+
+	return records, lineErrs, nil
+}
+
+// csvHeader - индексы колонок по именам; неизвестные и повторяющиеся колонки - ошибка
+func csvHeader(header []string) (map[string]int, []model.LineError) {
+	var lineErrs []model.LineError
+	columns := make(map[string]int, len(header))
+
+	for i, name := range header {
+		// Excel сохраняет UTF-8 с BOM в начале файла
+		if i == 0 {
+			name = strings.TrimPrefix(name, "\ufeff")
+		}
+		name = strings.ToLower(strings.TrimSpace(name))
+
+		switch _, dup := columns[name]; {
+		case !slices.Contains(csvColumns, name):
+			lineErrs = append(lineErrs, model.LineError{Line: 1, Field: name, Message: "unknown column"})
+		case dup:
+			lineErrs = append(lineErrs, model.LineError{Line: 1, Field: name, Message: "duplicate column"})
+		default:
+			columns[name] = i
+		}
+	}
+
+	if _, ok := columns["title"]; !ok && len(lineErrs) == 0 {
+		lineErrs = append(lineErrs, model.LineError{Line: 1, Field: "title", Message: "column is required"})
+	}
+
+	return columns, lineErrs
+}
+
+// csvRecord - строка CSV в Record; ошибки по каждой неверной ячейке
+func csvRecord(row []string, columns map[string]int, line int) (Record, []model.LineError) {
+	get := func(name string) string {
+		if i, ok := columns[name]; ok {
+			return strings.TrimSpace(row[i])
+		}
+		return ""
+	}
+
+	var lineErrs []model.LineError
+	rec := Record{
+		Title:       get("title"),
+		Description: get("description"),
+		Priority:    strings.ToLower(get("priority")),
+		Tags:        []string{},
+		Recurrence:  get("recurrence"),
+		Line:        line,
+	}
+
+	if v := get("completed"); v != "" {
+		completed, err := strconv.ParseBool(v)
+		if err != nil {
+			lineErrs = append(lineErrs, model.LineError{Line: line, Field: "completed", Message: "must be true or false"})
+		}
+		rec.Completed = completed
+	}
+
+	if v := get("due_at"); v != "" {
+		dueAt, err := parseTime(v)
+		if err != nil {
+			lineErrs = append(lineErrs, model.LineError{Line: line, Field: "due_at", Message: err.Error()})
+		}
+		rec.DueAt = &dueAt
+	}
+
+	for _, tag := range strings.Split(get("tags"), csvTagSeparator) {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			rec.Tags = append(rec.Tags, tag)
+		}
+	}
+
+	return rec, lineErrs
+}
+
+// csvLineError - *csv.ParseError как ошибка строки файла
+func csvLineError(err error) (model.LineError, bool) {
+	var parseErr *csv.ParseError
+	if !errors.As(err, &parseErr) {
+		return model.LineError{}, false
+	}
+
+	line := parseErr.StartLine
+	if line == 0 {
+		line = parseErr.Line
+	}
+
+	return model.LineError{Line: line, Message: fmt.Sprintf("invalid CSV: %v", parseErr.Err)}, true
+}
@@ -0,0 +1,398 @@
+package transfer
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"crud-example/internal/model"
+)
+
+// Формат времени iCalendar (RFC 5545): DATE-TIME в UTC и DATE
+const (
+	icsTimeFormat = "20060102T150405Z"
+	icsDateFormat = "20060102"
+)
+
+// icsMaxLineLength - строки длиннее 75 байт переносятся (folding, RFC 5545 3.1)
+const icsMaxLineLength = 75
+
+// icsPriority - приоритет задачи в шкале iCalendar: 1 - наивысший, 9 - наименьший
+var icsPriority = map[model.Priority]int{
+	model.PriorityUrgent: 1,
+	model.PriorityHigh:   3,
+	model.PriorityNormal: 5,
+	model.PriorityLow:    9,
+}
+
+// icsEncoder - календарь VCALENDAR, каждая задача - компонент VTODO
+type icsEncoder struct {
+	w       io.Writer
+	dtstamp string // DTSTAMP - время создания файла, одно на все задачи
+	err     error  // первая ошибка записи, дальше запись не выполняется
+}
+
+func newICSEncoder(w io.Writer, now time.Time) (*icsEncoder, error) {
+	e := &icsEncoder{w: w, dtstamp: now.UTC().Format(icsTimeFormat)}
+
+	e.line("BEGIN", "VCALENDAR")
+	e.line("VERSION", "2.0")
+	e.line("PRODID", "-//crud-example//todos//RU")
+
+	return e, e.err
+}
+
+func (e *icsEncoder) Encode(todo *model.Todo) error {
+	e.line("BEGIN", "VTODO")
+	e.line("UID", fmt.Sprintf("todo-%d@crud-example", todo.ID))
+	e.line("DTSTAMP", e.dtstamp)
+	e.line("CREATED", todo.CreatedAt.UTC().Format(icsTimeFormat))
+	e.line("LAST-MODIFIED", todo.UpdatedAt.UTC().Format(icsTimeFormat))
+	e.line("SUMMARY", icsEscape(todo.Title))
+
+	if todo.Description != "" {
+		e.line("DESCRIPTION", icsEscape(todo.Description))
+	}
+
+	if todo.DueAt != nil {
+		e.line("DUE", todo.DueAt.UTC().Format(icsTimeFormat))
+	}
+
+	// RRULE в VTODO повторяет задачу начиная с DUE - как и правило задачи
+	if todo.Recurrence != nil {
+		e.line("RRULE", todo.Recurrence.String())
+	}
+
+	if p, ok := icsPriority[todo.Priority]; ok {
+		e.line("PRIORITY", strconv.Itoa(p))
+	}
+
+	if todo.Completed {
+		e.line("STATUS", "COMPLETED")
+	} else {
+		e.line("STATUS", "NEEDS-ACTION")
+	}
+
+	if len(todo.Tags) > 0 {
+		categories := make([]string, 0, len(todo.Tags))
+		for _, tag := range todo.Tags {
+			categories = append(categories, icsEscape(tag))
+		}
+		e.line("CATEGORIES", strings.Join(categories, ","))
+	}
+
+	e.line("END", "VTODO")
+	return e.err
+}
+
+func (e *icsEncoder) Close() error {
+	e.line("END", "VCALENDAR")
+	return e.err
+}
+
+// line - пишет свойство "NAME:value", перенося длинные строки
+// Перенос - CRLF и пробел; многобайтовые символы UTF-8 не разрываются
+func (e *icsEncoder) line(name, value string) {
+	if e.err != nil {
+		return
+	}
+
+	s := name + ":" + value
+	var b strings.Builder
+
+	limit := icsMaxLineLength
+	for len(s) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(s[cut]) {
+			cut--
+		}
+		b.WriteString(s[:cut])
+		b.WriteString("\r\n ")
+		s = s[cut:]
+		limit = icsMaxLineLength - 1 // пробел в начале строки продолжения тоже считается
+	}
+	b.WriteString(s)
+	b.WriteString("\r\n")
+
+	_, e.err = io.WriteString(e.w, b.String())
+}
+
+// icsEscape - экранирует значение типа TEXT: \ ; , и перевод строки
+func icsEscape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`, "\r", "").Replace(s)
+}
+
+// icsUnescape - обратное к icsEscape
+func icsUnescape(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' || i == len(s)-1 {
+			b.WriteByte(s[i])
+			continue
+		}
+
+		i++
+		if s[i] == 'n' || s[i] == 'N' {
+			b.WriteByte('\n')
+		} else {
+			b.WriteByte(s[i])
+		}
+	}
+	return b.String()
+}
+
+// icsSplitList - делит значение-список по запятым, кроме экранированных (\,)
+func icsSplitList(s string) []string {
+	var (
+		items []string
+		start int
+	)
+
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case ',':
+			items = append(items, icsUnescape(s[start:i]))
+			start = i + 1
+		}
+	}
+
+	return append(items, icsUnescape(s[start:]))
+}
+
+// icsProperty - одна логическая строка iCalendar после склейки переносов
+type icsProperty struct {
+	line   int // строка файла, с которой начинается свойство
+	name   string
+	params map[string]string
+	value  string
+}
+
+// decodeICS - задачи из компонентов VTODO
+// Остальные компоненты (VEVENT, VTIMEZONE, ...) и неизвестные свойства пропускаются
+func decodeICS(r io.Reader) ([]Record, []model.LineError, error) {
+	props, err := readICSProperties(r)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if len(props) == 0 || props[0].name != "BEGIN" || !strings.EqualFold(props[0].value, "VCALENDAR") {
+		return nil, []model.LineError{{Line: 1, Message: "not an iCalendar file: expected BEGIN:VCALENDAR"}}, nil
+	}
+
+	var (
+		records  []Record
+		lineErrs []model.LineError
+		stack    []string // открытые компоненты: VCALENDAR, VTODO, VALARM, ...
+		rec      *Record
+		recErrs  []model.LineError
+	)
+
+	for _, p := range props {
+		switch p.name {
+		case "BEGIN":
+			component := strings.ToUpper(p.value)
+			if component == "VTODO" && len(stack) == 1 {
+				rec = &Record{Line: p.line, Tags: []string{}}
+				recErrs = nil
+			}
+			stack = append(stack, component)
+			continue
+		case "END":
+			if len(stack) == 0 || stack[len(stack)-1] != strings.ToUpper(p.value) {
+				lineErrs = append(lineErrs, model.LineError{Line: p.line, Message: "unexpected END:" + p.value})
+				return records, lineErrs, nil
+			}
+			stack = stack[:len(stack)-1]
+
+			if rec != nil && len(stack) == 1 {
+				if len(recErrs) > 0 {
+					lineErrs = append(lineErrs, recErrs...)
+				} else {
+					records = append(records, *rec)
+				}
+				rec = nil
+			}
+			continue
+		}
+
+		// Свойства вложенных в VTODO компонентов (например, VALARM) не относятся к задаче
+		if rec == nil || len(stack) != 2 {
+			continue
+		}
+
+		if err := rec.setICSProperty(p); err != nil {
+			recErrs = append(recErrs, *err)
+		}
+	}
+
+	if rec != nil {
+		lineErrs = append(lineErrs, model.LineError{Line: rec.Line, Message: "VTODO is not closed"})
+	}
+
+	return records, lineErrs, nil
+}
+
+// setICSProperty - переносит свойство VTODO в запись
+func (rec *Record) setICSProperty(p icsProperty) *model.LineError {
+	switch p.name {
+	case "SUMMARY":
+		rec.Title = icsUnescape(p.value)
+	case "DESCRIPTION":
+		rec.Description = icsUnescape(p.value)
+	case "DUE":
+		dueAt, err := parseICSTime(p.value, p.params)
+		if err != nil {
+			return &model.LineError{Line: p.line, Field: "due_at", Message: err.Error()}
+		}
+		rec.DueAt = &dueAt
+	case "PRIORITY":
+		n, err := strconv.Atoi(p.value)
+		if err != nil || n < 0 || n > 9 {
+			return &model.LineError{Line: p.line, Field: "priority", Message: "PRIORITY must be a number from 0 to 9"}
+		}
+		rec.Priority = priorityFromICS(n)
+	case "RRULE":
+		rec.Recurrence = p.value
+	case "STATUS":
+		rec.Completed = strings.EqualFold(p.value, "COMPLETED")
+	case "COMPLETED":
+		rec.Completed = true
+	case "CATEGORIES":
+		for _, tag := range icsSplitList(p.value) {
+			if tag = strings.TrimSpace(tag); tag != "" {
+				rec.Tags = append(rec.Tags, tag)
+			}
+		}
+	}
+	return nil
+}
+
+// priorityFromICS - 1-2 → urgent, 3-4 → high, 0 и 5 → normal, 6-9 → low
+func priorityFromICS(n int) string {
+	switch {
+	case n >= 1 && n <= 2:
+		return model.PriorityUrgent.String()
+	case n >= 3 && n <= 4:
+		return model.PriorityHigh.String()
+	case n >= 6:
+		return model.PriorityLow.String()
+	}
+	return model.PriorityNormal.String()
+}
+
+// parseICSTime - DATE (20250201), DATE-TIME в UTC (20250201T150000Z),
+// с TZID (DUE;TZID=Europe/Moscow:20250201T180000) или "плавающее" время (считается UTC)
+func parseICSTime(value string, params map[string]string) (time.Time, error) {
+	if params["VALUE"] == "DATE" || len(value) == len(icsDateFormat) {
+		t, err := time.Parse(icsDateFormat, value)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid date %q", value)
+		}
+		return t, nil
+	}
+
+	if strings.HasSuffix(value, "Z") {
+		t, err := time.Parse(icsTimeFormat, value)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid date-time %q", value)
+		}
+		return t, nil
+	}
+
+	loc := time.UTC
+	if tzid := params["TZID"]; tzid != "" {
+		var err error
+		if loc, err = time.LoadLocation(tzid); err != nil {
+			return time.Time{}, fmt.Errorf("unknown time zone %q", tzid)
+		}
+	}
+
+	t, err := time.ParseInLocation(strings.TrimSuffix(icsTimeFormat, "Z"), value, loc)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid date-time %q", value)
+	}
+	return t.UTC(), nil
+}
+
+// readICSProperties - читает файл, склеивает перенесенные строки и разбирает свойства
+func readICSProperties(r io.Reader) ([]icsProperty, error) {
+	var (
+		props   []icsProperty
+		current strings.Builder
+		start   int
+	)
+
+	flush := func() {
+		if current.Len() > 0 {
+			props = append(props, parseICSProperty(start, current.String()))
+			current.Reset()
+		}
+	}
+
+	br := bufio.NewReader(r)
+	for line := 1; ; line++ {
+		s, err := br.ReadString('\n')
+		if err != nil && !errors.Is(err, io.EOF) {
+			return nil, err
+		}
+
+		s = strings.TrimRight(s, "\r\n")
+		switch {
+		case s == "":
+		case s[0] == ' ' || s[0] == '\t':
+			// Продолжение предыдущей строки: первый пробельный символ - часть переноса
+			current.WriteString(s[1:])
+		default:
+			flush()
+			start = line
+			current.WriteString(s)
+		}
+
+		if errors.Is(err, io.EOF) {
+			break
+		}
+	}
+	flush()
+
+	return props, nil
+}
+
+// parseICSProperty - "NAME;PARAM=value;PARAM2=\"a:b\":value"
+// Двоеточие внутри кавычек в параметрах не считается разделителем
+func parseICSProperty(line int, s string) icsProperty {
+	p := icsProperty{line: line, params: make(map[string]string)}
+
+	quoted := false
+	sep := -1
+	for i := 0; i < len(s) && sep < 0; i++ {
+		switch s[i] {
+		case '"':
+			quoted = !quoted
+		case ':':
+			if !quoted {
+				sep = i
+			}
+		}
+	}
+
+	head := s
+	if sep >= 0 {
+		head, p.value = s[:sep], s[sep+1:]
+	}
+
+	parts := strings.Split(head, ";")
+	p.name = strings.ToUpper(strings.TrimSpace(parts[0]))
+	for _, param := range parts[1:] {
+		key, value, _ := strings.Cut(param, "=")
+		p.params[strings.ToUpper(key)] = strings.Trim(value, `"`)
+	}
+
+	return p
+}
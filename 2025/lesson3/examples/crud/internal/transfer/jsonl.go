@@ -0,0 +1,132 @@
+package transfer
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"reflect"
+	"time"
+
+	"crud-example/internal/model"
+	"crud-example/internal/validate"
+)
+
+// jsonlEncoder - одна задача - один JSON объект в строке (JSON Lines)
+type jsonlEncoder struct {
+	enc *json.Encoder
+}
+
+func newJSONLEncoder(w io.Writer) *jsonlEncoder {
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	return &jsonlEncoder{enc: enc}
+}
+
+// Encode - json.Encoder сам добавляет перевод строки после объекта
+func (e *jsonlEncoder) Encode(todo *model.Todo) error {
+	return e.enc.Encode(NewRecord(todo))
+}
+
+func (e *jsonlEncoder) Close() error {
+	return nil
+}
+
+// decodeJSONL - каждая непустая строка - JSON объект с полями Record
+// Поля, которых нет в Record, считаются ошибкой, как и в JSON запросах API
+func decodeJSONL(r io.Reader) ([]Record, []model.LineError, error) {
+	var (
+		records  []Record
+		lineErrs []model.LineError
+	)
+
+	// bufio.Reader, а не Scanner: у Scanner ограничена длина строки
+	br := bufio.NewReader(r)
+	for line := 1; ; line++ {
+		data, err := br.ReadBytes('\n')
+		if err != nil && !errors.Is(err, io.EOF) {
+			return nil, nil, err
+		}
+
+		if len(bytes.TrimSpace(data)) > 0 {
+			rec, decodeErr := decodeJSONLine(data)
+			if decodeErr != nil {
+				lineErrs = append(lineErrs, jsonLineError(line, decodeErr))
+			} else {
+				rec.Line = line
+				records = append(records, rec)
+			}
+		}
+
+		if errors.Is(err, io.EOF) {
+			break
+		}
+	}
+
+	if len(records) == 0 && len(lineErrs) == 0 {
+		lineErrs = append(lineErrs, model.LineError{Line: 1, Message: "file is empty"})
+	}
+
+	return records, lineErrs, nil
+}
+
+func decodeJSONLine(data []byte) (Record, error) {
+	var rec Record
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+
+	if err := dec.Decode(&rec); err != nil {
+		return rec, err
+	}
+	if dec.More() {
+		return rec, errors.New("unexpected data after JSON object")
+	}
+
+	return rec, nil
+}
+
+// jsonLineError - ошибка encoding/json как ошибка строки файла
+func jsonLineError(line int, err error) model.LineError {
+	var (
+		typeErr *json.UnmarshalTypeError
+		timeErr *time.ParseError
+	)
+
+	if field, ok := validate.UnknownJSONField(err); ok {
+		return model.LineError{Line: line, Field: field, Message: "unknown field"}
+	}
+
+	switch {
+	case errors.As(err, &typeErr):
+		if typeErr.Field == "" {
+			return model.LineError{Line: line, Message: "must be a JSON object"}
+		}
+		return model.LineError{Line: line, Field: typeErr.Field, Message: "wrong type: expected " + jsonKind(typeErr.Type)}
+	case errors.As(err, &timeErr):
+		// time.Time.UnmarshalJSON не сообщает имя поля
+		return model.LineError{Line: line, Message: "timestamps must be in RFC3339 format"}
+	}
+
+	return model.LineError{Line: line, Message: "invalid JSON"}
+}
+
+// jsonKind - как тип поля Record выглядит в JSON
+func jsonKind(t reflect.Type) string {
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return "string"
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Int, reflect.Int64:
+		return "number"
+	case reflect.Slice:
+		return "array"
+	}
+	return "object"
+}
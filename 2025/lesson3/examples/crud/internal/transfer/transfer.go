@@ -0,0 +1,157 @@
+// Package transfer - экспорт и импорт задач в файлах CSV, JSON Lines и iCalendar
+//
+// Экспорт пишет задачи по одной через Encoder, поэтому не держит весь список в памяти.
+// Импорт (Decode) разбирает файл целиком и возвращает записи вместе с ошибками
+// по строкам: одна битая строка не мешает найти ошибки в остальных
+package transfer
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"crud-example/internal/model"
+)
+
+// Format - формат файла
+type Format string
+
+// Поддерживаемые форматы
+const (
+	FormatCSV   Format = "csv"
+	FormatJSONL Format = "jsonl"
+	FormatICS   Format = "ics"
+)
+
+// ParseFormat - "csv", "jsonl" или "ics"
+func ParseFormat(s string) (Format, bool) {
+	switch f := Format(strings.ToLower(s)); f {
+	case FormatCSV, FormatJSONL, FormatICS:
+		return f, true
+	}
+	return "", false
+}
+
+// FormatFromContentType - формат по заголовку Content-Type ("text/csv; charset=utf-8" → csv)
+func FormatFromContentType(contentType string) (Format, bool) {
+	mediaType, _, _ := strings.Cut(contentType, ";")
+
+	switch strings.ToLower(strings.TrimSpace(mediaType)) {
+	case "text/csv":
+		return FormatCSV, true
+	case "application/jsonl", "application/x-ndjson", "application/x-jsonlines":
+		return FormatJSONL, true
+	case "text/calendar":
+		return FormatICS, true
+	}
+	return "", false
+}
+
+// ContentType - значение заголовка Content-Type для файла экспорта
+func (f Format) ContentType() string {
+	switch f {
+	case FormatJSONL:
+		return "application/x-ndjson"
+	case FormatICS:
+		return "text/calendar; charset=utf-8"
+	}
+	return "text/csv; charset=utf-8"
+}
+
+// Record - задача в файле импорта или экспорта
+// Правила validate те же, что у handler.CreateTodoRequest
+type Record struct {
+	ID          int64      `json:"id,omitempty"` // при импорте не используется: задачи получают новые ID
+	Title       string     `json:"title" validate:"required,max=255"`
+	Description string     `json:"description" validate:"max=5000"`
+	Completed   bool       `json:"completed"`
+	DueAt       *time.Time `json:"due_at" validate:"after=2000-01-01,before=2100-01-01"`
+	Priority    string     `json:"priority" validate:"oneof=low normal high urgent"`
+	Tags        []string   `json:"tags" validate:"max=20,itemmax=50"`
+	Recurrence  string     `json:"recurrence,omitempty" validate:"max=255"` // RRULE; разбирается сервисом
+	CreatedAt   *time.Time `json:"created_at,omitempty"`                    // при импорте не используется
+	UpdatedAt   *time.Time `json:"updated_at,omitempty"`                    // при импорте не используется
+
+	Line int `json:"-"` // строка файла, с которой начинается запись (только при импорте)
+}
+
+// NewRecord - запись для экспорта задачи; время - в UTC
+func NewRecord(todo *model.Todo) Record {
+	rec := Record{
+		ID:          todo.ID,
+		Title:       todo.Title,
+		Description: todo.Description,
+		Completed:   todo.Completed,
+		Priority:    todo.Priority.String(),
+		Tags:        todo.Tags,
+	}
+
+	if rec.Tags == nil {
+		rec.Tags = []string{}
+	}
+
+	if todo.Recurrence != nil {
+		rec.Recurrence = todo.Recurrence.String()
+	}
+
+	if todo.DueAt != nil {
+		dueAt := todo.DueAt.UTC()
+		rec.DueAt = &dueAt
+	}
+
+	createdAt, updatedAt := todo.CreatedAt.UTC(), todo.UpdatedAt.UTC()
+	rec.CreatedAt, rec.UpdatedAt = &createdAt, &updatedAt
+
+	return rec
+}
+
+// Encoder - пишет задачи в файл экспорта
+type Encoder interface {
+	// Encode - добавляет задачу в файл
+	Encode(todo *model.Todo) error
+	// Close - дописывает окончание файла и сбрасывает буфер; сам writer не закрывает
+	Close() error
+}
+
+// NewEncoder - Encoder для формата format, пишущий в w
+// Заголовок файла (строка колонок CSV, BEGIN:VCALENDAR) пишется сразу
+func NewEncoder(w io.Writer, format Format) (Encoder, error) {
+	switch format {
+	case FormatCSV:
+		return newCSVEncoder(w)
+	case FormatJSONL:
+		return newJSONLEncoder(w), nil
+	case FormatICS:
+		return newICSEncoder(w, time.Now())
+	}
+	return nil, fmt.Errorf("unknown format %q", format)
+}
+
+// Decode - разбирает файл импорта
+// Записи с ошибками разбора не попадают в результат, их ошибки возвращаются в lineErrs.
+// err - ошибка чтения (например, *http.MaxBytesError), а не содержимого файла
+func Decode(r io.Reader, format Format) (records []Record, lineErrs []model.LineError, err error) {
+	switch format {
+	case FormatCSV:
+		return decodeCSV(r)
+	case FormatJSONL:
+		return decodeJSONL(r)
+	case FormatICS:
+		return decodeICS(r)
+	}
+	return nil, nil, fmt.Errorf("unknown format %q", format)
+}
+
+// parseTime - RFC3339 ("2025-02-01T18:00:00+03:00") или дата ("2025-02-01"), результат в UTC
+func parseTime(v string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		return t.UTC(), nil
+	}
+
+	t, err := time.Parse(time.DateOnly, v)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid time %q (expected RFC3339 or YYYY-MM-DD)", v)
+	}
+	return t, nil
+}
@@ -0,0 +1,188 @@
+package transfer
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+	_ "time/tzdata" // TZID в тестах не должен зависеть от базы часовых поясов в системе
+
+	"crud-example/internal/model"
+)
+
+// TestRoundTrip - задача, выгруженная в любом формате, загружается обратно без потерь
+func TestRoundTrip(t *testing.T) {
+	dueAt := time.Date(2025, 2, 1, 15, 0, 0, 0, time.UTC)
+	created := time.Date(2025, 1, 10, 9, 30, 0, 0, time.UTC)
+	weekly, err := model.ParseRecurrence("FREQ=WEEKLY;BYDAY=MO,TH;COUNT=8")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	todos := []*model.Todo{
+		{
+			ID:          1,
+			Title:       "Купить молоко; хлеб, сыр",
+			Description: "Строка 1\nСтрока 2 с \\ и \"кавычками\" и очень длинным текстом, который точно не поместится в 75 байт",
+			Completed:   true,
+			DueAt:       &dueAt,
+			Priority:    model.PriorityUrgent,
+			Tags:        []string{"дом", "магазин"},
+			Recurrence:  weekly,
+			CreatedAt:   created,
+			UpdatedAt:   created,
+		},
+		{
+			ID:        2,
+			Title:     "Без срока",
+			Priority:  model.PriorityLow,
+			CreatedAt: created,
+			UpdatedAt: created,
+		},
+	}
+
+	for _, format := range []Format{FormatCSV, FormatJSONL, FormatICS} {
+		t.Run(string(format), func(t *testing.T) {
+			var buf bytes.Buffer
+			enc, err := NewEncoder(&buf, format)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, todo := range todos {
+				if err := enc.Encode(todo); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := enc.Close(); err != nil {
+				t.Fatal(err)
+			}
+
+			records, lineErrs, err := Decode(&buf, format)
+			if err != nil || len(lineErrs) > 0 {
+				t.Fatalf("Decode: err=%v lineErrs=%v\n%s", err, lineErrs, buf.String())
+			}
+			if len(records) != len(todos) {
+				t.Fatalf("got %d records, want %d", len(records), len(todos))
+			}
+
+			for i, rec := range records {
+				want := NewRecord(todos[i])
+				got := Record{
+					Title:       rec.Title,
+					Description: rec.Description,
+					Completed:   rec.Completed,
+					DueAt:       rec.DueAt,
+					Priority:    rec.Priority,
+					Tags:        rec.Tags,
+					Recurrence:  rec.Recurrence,
+				}
+				want.ID, want.CreatedAt, want.UpdatedAt = 0, nil, nil
+
+				if !reflect.DeepEqual(got, want) {
+					t.Errorf("record %d:\n got %+v\nwant %+v", i, got, want)
+				}
+				if rec.Line == 0 {
+					t.Errorf("record %d: line is not set", i)
+				}
+			}
+		})
+	}
+}
+
+// TestICSFolding - строки файла iCalendar не длиннее 75 байт
+func TestICSFolding(t *testing.T) {
+	var buf bytes.Buffer
+	enc, _ := NewEncoder(&buf, FormatICS)
+	enc.Encode(&model.Todo{ID: 1, Title: strings.Repeat("задача ", 30), Priority: model.PriorityNormal})
+	enc.Close()
+
+	for _, line := range strings.Split(buf.String(), "\r\n") {
+		if len(line) > icsMaxLineLength {
+			t.Errorf("line is %d bytes: %q", len(line), line)
+		}
+	}
+}
+
+// TestDecodeLineErrors - ошибки по строкам, корректные записи разбираются дальше
+func TestDecodeLineErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		format  Format
+		input   string
+		records int
+		want    []model.LineError
+	}{
+		{
+			name:    "csv bad cells",
+			format:  FormatCSV,
+			input:   "title,completed,due_at\nok,false,2025-02-01\nbad,maybe,2025-13-01\nshort\n",
+			records: 1,
+			want: []model.LineError{
+				{Line: 3, Field: "completed", Message: "must be true or false"},
+				{Line: 3, Field: "due_at", Message: `invalid time "2025-13-01" (expected RFC3339 or YYYY-MM-DD)`},
+				{Line: 4, Message: "invalid CSV: wrong number of fields"},
+			},
+		},
+		{
+			name:   "csv unknown column",
+			format: FormatCSV,
+			input:  "title,owner\nx,y\n",
+			want:   []model.LineError{{Line: 1, Field: "owner", Message: "unknown column"}},
+		},
+		{
+			name:    "jsonl",
+			format:  FormatJSONL,
+			input:   "{\"title\":\"ok\"}\n\n{\"title\":1}\n{\"title\":\"x\",\"owner\":2}\nnot json\n",
+			records: 1,
+			want: []model.LineError{
+				{Line: 3, Field: "title", Message: "wrong type: expected string"},
+				{Line: 4, Field: "owner", Message: "unknown field"},
+				{Line: 5, Message: "invalid JSON"},
+			},
+		},
+		{
+			name:   "ics",
+			format: FormatICS,
+			input: "BEGIN:VCALENDAR\r\nBEGIN:VTODO\r\nSUMMARY:ok\r\nDUE;TZID=Europe/Moscow:20250201T180000\r\nEND:VTODO\r\n" +
+				"BEGIN:VTODO\r\nSUMMARY:bad\r\nPRIORITY:high\r\nEND:VTODO\r\nEND:VCALENDAR\r\n",
+			records: 1,
+			want:    []model.LineError{{Line: 8, Field: "priority", Message: "PRIORITY must be a number from 0 to 9"}},
+		},
+		{
+			name:   "not ics",
+			format: FormatICS,
+			input:  "title\nx\n",
+			want:   []model.LineError{{Line: 1, Message: "not an iCalendar file: expected BEGIN:VCALENDAR"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			records, lineErrs, err := Decode(strings.NewReader(tt.input), tt.format)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(records) != tt.records {
+				t.Errorf("got %d records, want %d", len(records), tt.records)
+			}
+			if !reflect.DeepEqual(lineErrs, tt.want) {
+				t.Errorf("line errors:\n got %+v\nwant %+v", lineErrs, tt.want)
+			}
+		})
+	}
+}
+
+// TestICSTimeZone - DUE с TZID переводится в UTC
+func TestICSTimeZone(t *testing.T) {
+	input := "BEGIN:VCALENDAR\r\nBEGIN:VTODO\r\nSUMMARY:x\r\nDUE;TZID=Europe/Moscow:20250201T180000\r\nEND:VTODO\r\nEND:VCALENDAR\r\n"
+
+	records, _, err := Decode(strings.NewReader(input), FormatICS)
+	if err != nil || len(records) != 1 || records[0].DueAt == nil {
+		t.Fatalf("records=%v err=%v", records, err)
+	}
+
+	if want := time.Date(2025, 2, 1, 15, 0, 0, 0, time.UTC); !records[0].DueAt.Equal(want) {
+		t.Errorf("due_at = %v, want %v", records[0].DueAt, want)
+	}
+}
@@ -0,0 +1,24 @@
+package validate
+
+import (
+	"strings"
+)
+
+// unknownFieldPrefix - начало текста ошибки json.Decoder с DisallowUnknownFields
+const unknownFieldPrefix = "json: unknown field "
+
+// UnknownJSONField - имя поля из ошибки "json: unknown field" (Decoder.DisallowUnknownFields)
+// encoding/json не экспортирует тип этой ошибки, имя поля есть только в тексте.
+// ok == false - err другая ошибка
+func UnknownJSONField(err error) (field string, ok bool) {
+	if err == nil {
+		return "", false
+	}
+
+	rest, ok := strings.CutPrefix(err.Error(), unknownFieldPrefix)
+	if !ok {
+		return "", false
+	}
+
+	return strings.Trim(rest, `"`), true
+}
@@ -0,0 +1,43 @@
+package validate
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// TestUnknownJSONField - текст ошибки берется у настоящего json.Decoder:
+// если encoding/json изменит формат, тест это заметит
+func TestUnknownJSONField(t *testing.T) {
+	decode := func(body string) error {
+		var dst struct {
+			Title string `json:"title"`
+		}
+		dec := json.NewDecoder(strings.NewReader(body))
+		dec.DisallowUnknownFields()
+		return dec.Decode(&dst)
+	}
+
+	tests := []struct {
+		name      string
+		err       error
+		wantField string
+		wantOK    bool
+	}{
+		{name: "unknown field", err: decode(`{"title":"a","owner":1}`), wantField: "owner", wantOK: true},
+		{name: "type error", err: decode(`{"title":1}`)},
+		{name: "syntax error", err: decode(`{"title":`)},
+		{name: "other error", err: errors.New("unknown field owner")},
+		{name: "nil", err: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			field, ok := UnknownJSONField(tt.err)
+			if field != tt.wantField || ok != tt.wantOK {
+				t.Errorf("UnknownJSONField(%v) = %q, %v; want %q, %v", tt.err, field, ok, tt.wantField, tt.wantOK)
+			}
+		})
+	}
+}
@@ -0,0 +1,227 @@
+// Package validate - декларативная проверка DTO по тегам `validate`
+//
+//	type CreateTodoRequest struct {
+//		Title    string `json:"title" validate:"required,max=255"`
+//		Priority string `json:"priority" validate:"oneof=low normal high urgent"`
+//	}
+//
+// Правила (через запятую):
+//
+//	required      - значение не нулевое: непустая строка (без учета пробелов), не nil, непустой slice
+//	notblank      - строка, если передана, не состоит из одних пробелов (удобно для *string в PATCH)
+//	min=N, max=N  - длина строки в символах (рунах), длина slice или значение числа
+//	itemmax=N     - длина каждой строки в []string в символах
+//	oneof=a b c   - строка из перечисления (пустая строка пропускается, для нее есть required)
+//	after=DATE    - время (time.Time) позже DATE, DATE - "2006-01-02" или RFC3339
+//	before=DATE   - время раньше DATE
+//
+// Правила, кроме required, к nil-указателям не применяются: поле не передано.
+// Ошибка в самом теге - ошибка программиста, поэтому Struct паникует, как regexp.MustCompile
+package validate
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"crud-example/internal/model"
+)
+
+// Struct - проверяет поля структуры (или указателя на нее) по тегам `validate`
+// Возвращает *model.ValidationErrors со всеми ошибками сразу или nil.
+// Имена полей в ошибках - из тега `json`; встроенные структуры проверяются как свои поля
+func Struct(v any) error {
+	value := reflect.Indirect(reflect.ValueOf(v))
+	if value.Kind() != reflect.Struct {
+		panic(fmt.Sprintf("validate: %T is not a struct", v))
+	}
+
+	var errs model.ValidationErrors
+	checkStruct(value, &errs)
+
+	if len(errs.Fields) == 0 {
+		return nil
+	}
+	return &errs
+}
+
+func checkStruct(value reflect.Value, errs *model.ValidationErrors) {
+	typ := value.Type()
+
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		if field.Anonymous && field.Type.Kind() == reflect.Struct {
+			checkStruct(value.Field(i), errs)
+			continue
+		}
+
+		tag := field.Tag.Get("validate")
+		if tag == "" {
+			continue
+		}
+
+		name := jsonName(field)
+		for _, rule := range strings.Split(tag, ",") {
+			if message := checkRule(value.Field(i), rule); message != "" {
+				errs.Fields = append(errs.Fields, model.FieldError{Field: name, Message: message})
+				// Одной ошибки на поле достаточно: "обязательно" и "слишком длинное" вместе не нужны
+				break
+			}
+		}
+	}
+}
+
+// checkRule - проверяет одно правило, возвращает текст ошибки или ""
+func checkRule(value reflect.Value, rule string) string {
+	name, arg, _ := strings.Cut(rule, "=")
+
+	if name == "required" {
+		if isZero(value) {
+			return "is required"
+		}
+		return ""
+	}
+
+	if value.Kind() == reflect.Pointer {
+		if value.IsNil() {
+			return ""
+		}
+		value = value.Elem()
+	}
+
+	switch name {
+	case "notblank":
+		if value.Kind() == reflect.String && strings.TrimSpace(value.String()) == "" {
+			return "cannot be blank"
+		}
+	case "min":
+		n := mustInt(rule, arg)
+		switch length, kind := measure(value, rule); kind {
+		case reflect.String:
+			if length < n {
+				return fmt.Sprintf("too short (min %d characters)", n)
+			}
+		case reflect.Slice:
+			if length < n {
+				return fmt.Sprintf("too few items (min %d)", n)
+			}
+		default:
+			if length < n {
+				return fmt.Sprintf("must be at least %d", n)
+			}
+		}
+	case "max":
+		n := mustInt(rule, arg)
+		switch length, kind := measure(value, rule); kind {
+		case reflect.String:
+			if length > n {
+				return fmt.Sprintf("too long (max %d characters)", n)
+			}
+		case reflect.Slice:
+			if length > n {
+				return fmt.Sprintf("too many items (max %d)", n)
+			}
+		default:
+			if length > n {
+				return fmt.Sprintf("must be at most %d", n)
+			}
+		}
+	case "itemmax":
+		n := mustInt(rule, arg)
+		items, ok := value.Interface().([]string)
+		if !ok {
+			panic(fmt.Sprintf("validate: rule %q requires []string, got %s", rule, value.Type()))
+		}
+		for _, item := range items {
+			if int64(utf8.RuneCountInString(item)) > n {
+				return fmt.Sprintf("item too long (max %d characters)", n)
+			}
+		}
+	case "oneof":
+		if value.Kind() != reflect.String {
+			panic(fmt.Sprintf("validate: rule %q requires string, got %s", rule, value.Type()))
+		}
+		allowed := strings.Fields(arg)
+		if s := value.String(); s != "" && !slices.Contains(allowed, s) {
+			return "must be one of: " + strings.Join(allowed, ", ")
+		}
+	case "after", "before":
+		bound := mustTime(rule, arg)
+		t, ok := value.Interface().(time.Time)
+		if !ok {
+			panic(fmt.Sprintf("validate: rule %q requires time.Time, got %s", rule, value.Type()))
+		}
+		if name == "after" && !t.After(bound) {
+			return "must be after " + arg
+		}
+		if name == "before" && !t.Before(bound) {
+			return "must be before " + arg
+		}
+	default:
+		panic(fmt.Sprintf("validate: unknown rule %q", rule))
+	}
+
+	return ""
+}
+
+// isZero - значение не передано: nil, пустая строка (или одни пробелы), пустой slice, ноль
+func isZero(value reflect.Value) bool {
+	switch value.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		return value.IsNil()
+	case reflect.String:
+		return strings.TrimSpace(value.String()) == ""
+	case reflect.Slice, reflect.Map:
+		return value.Len() == 0
+	}
+	return value.IsZero()
+}
+
+// measure - длина строки в рунах, длина slice или значение целого числа
+func measure(value reflect.Value, rule string) (int64, reflect.Kind) {
+	switch value.Kind() {
+	case reflect.String:
+		return int64(utf8.RuneCountInString(value.String())), reflect.String
+	case reflect.Slice, reflect.Map:
+		return int64(value.Len()), reflect.Slice
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return value.Int(), reflect.Int64
+	}
+	panic(fmt.Sprintf("validate: rule %q cannot be applied to %s", rule, value.Type()))
+}
+
+func mustInt(rule, arg string) int64 {
+	n, err := strconv.ParseInt(arg, 10, 64)
+	if err != nil {
+		panic(fmt.Sprintf("validate: rule %q: invalid number %q", rule, arg))
+	}
+	return n
+}
+
+func mustTime(rule, arg string) time.Time {
+	if t, err := time.Parse(time.DateOnly, arg); err == nil {
+		return t
+	}
+	t, err := time.Parse(time.RFC3339, arg)
+	if err != nil {
+		panic(fmt.Sprintf("validate: rule %q: invalid date %q", rule, arg))
+	}
+	return t
+}
+
+// jsonName - имя поля в JSON: из тега `json`, иначе имя поля Go
+func jsonName(field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	if name == "" || name == "-" {
+		return field.Name
+	}
+	return name
+}
@@ -0,0 +1,209 @@
+package validate
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"crud-example/internal/model"
+)
+
+type BaseRequest struct {
+	ID int64 `json:"id" validate:"min=1"`
+}
+
+type testRequest struct {
+	BaseRequest
+	Title    string     `json:"title" validate:"required,min=3,max=6"`
+	Note     *string    `json:"note,omitempty" validate:"notblank,max=3"`
+	Priority string     `json:"priority" validate:"oneof=low normal high"`
+	Tags     []string   `json:"tags" validate:"max=2,itemmax=4"`
+	Count    int        `json:"count" validate:"min=0,max=10"`
+	DueAt    *time.Time `json:"due_at" validate:"after=2025-01-01,before=2030-01-01T00:00:00Z"`
+	Owner    *int64     `validate:"required"`
+	internal string     `validate:"unknown"` // неэкспортируемые поля не проверяются
+}
+
+func TestStruct(t *testing.T) {
+	owner := int64(1)
+	str := func(s string) *string { return &s }
+	at := func(s string) *time.Time {
+		t, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			panic(err)
+		}
+		return &t
+	}
+
+	// valid - запрос без ошибок; каждый тест меняет в нем одно поле
+	valid := func() testRequest {
+		return testRequest{BaseRequest: BaseRequest{ID: 1}, Title: "Купить", Priority: "high", Owner: &owner}
+	}
+
+	tests := []struct {
+		name   string
+		modify func(r *testRequest)
+		want   []model.FieldError // nil - ошибок нет
+	}{
+		{name: "valid", modify: func(r *testRequest) {}},
+		{
+			name:   "max counts runes, not bytes",
+			modify: func(r *testRequest) { r.Title = "привет" }, // 6 рун, 12 байт
+		},
+		{
+			name:   "max",
+			modify: func(r *testRequest) { r.Title = "приветы" },
+			want:   []model.FieldError{{Field: "title", Message: "too long (max 6 characters)"}},
+		},
+		{
+			name:   "min counts runes",
+			modify: func(r *testRequest) { r.Title = "ёж" }, // 2 руны, 4 байта
+			want:   []model.FieldError{{Field: "title", Message: "too short (min 3 characters)"}},
+		},
+		{
+			name:   "required is checked first, one error per field",
+			modify: func(r *testRequest) { r.Title = "   " },
+			want:   []model.FieldError{{Field: "title", Message: "is required"}},
+		},
+		{
+			name:   "nil pointer skips rules",
+			modify: func(r *testRequest) { r.Note, r.DueAt = nil, nil },
+		},
+		{
+			name:   "pointer value is checked",
+			modify: func(r *testRequest) { r.Note = str("  ") },
+			want:   []model.FieldError{{Field: "note", Message: "cannot be blank"}},
+		},
+		{
+			name:   "max on pointer",
+			modify: func(r *testRequest) { r.Note = str("длинно") },
+			want:   []model.FieldError{{Field: "note", Message: "too long (max 3 characters)"}},
+		},
+		{
+			name:   "required nil pointer, Go field name without json tag",
+			modify: func(r *testRequest) { r.Owner = nil },
+			want:   []model.FieldError{{Field: "Owner", Message: "is required"}},
+		},
+		{
+			name:   "oneof skips empty string",
+			modify: func(r *testRequest) { r.Priority = "" },
+		},
+		{
+			name:   "oneof",
+			modify: func(r *testRequest) { r.Priority = "urgent" },
+			want:   []model.FieldError{{Field: "priority", Message: "must be one of: low, normal, high"}},
+		},
+		{
+			name:   "max on slice",
+			modify: func(r *testRequest) { r.Tags = []string{"a", "b", "c"} },
+			want:   []model.FieldError{{Field: "tags", Message: "too many items (max 2)"}},
+		},
+		{
+			name:   "itemmax counts runes",
+			modify: func(r *testRequest) { r.Tags = []string{"дом", "сад"} },
+		},
+		{
+			name:   "itemmax",
+			modify: func(r *testRequest) { r.Tags = []string{"дом", "работа"} },
+			want:   []model.FieldError{{Field: "tags", Message: "item too long (max 4 characters)"}},
+		},
+		{
+			name:   "max on number",
+			modify: func(r *testRequest) { r.Count = 11 },
+			want:   []model.FieldError{{Field: "count", Message: "must be at most 10"}},
+		},
+		{
+			name:   "min on number",
+			modify: func(r *testRequest) { r.Count = -1 },
+			want:   []model.FieldError{{Field: "count", Message: "must be at least 0"}},
+		},
+		{
+			name:   "after and before",
+			modify: func(r *testRequest) { r.DueAt = at("2025-06-01T12:00:00Z") },
+		},
+		{
+			name:   "after is exclusive",
+			modify: func(r *testRequest) { r.DueAt = at("2025-01-01T00:00:00Z") },
+			want:   []model.FieldError{{Field: "due_at", Message: "must be after 2025-01-01"}},
+		},
+		{
+			name:   "before",
+			modify: func(r *testRequest) { r.DueAt = at("2031-01-01T00:00:00Z") },
+			want:   []model.FieldError{{Field: "due_at", Message: "must be before 2030-01-01T00:00:00Z"}},
+		},
+		{
+			name:   "embedded struct and several fields at once",
+			modify: func(r *testRequest) { r.ID, r.Priority = 0, "none" },
+			want: []model.FieldError{
+				{Field: "id", Message: "must be at least 1"},
+				{Field: "priority", Message: "must be one of: low, normal, high"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := valid()
+			tt.modify(&req)
+
+			err := Struct(&req)
+			if tt.want == nil {
+				if err != nil {
+					t.Fatalf("Struct() = %v, want nil", err)
+				}
+				return
+			}
+
+			var errs *model.ValidationErrors
+			if !errors.As(err, &errs) || !errors.Is(err, model.ErrValidation) {
+				t.Fatalf("Struct() = %v, want *model.ValidationErrors", err)
+			}
+			if !slices.Equal(errs.Fields, tt.want) {
+				t.Errorf("Struct() fields = %v, want %v", errs.Fields, tt.want)
+			}
+		})
+	}
+}
+
+// TestStructPanicsOnMalformedTag - ошибка в теге - ошибка программиста, а не клиента
+func TestStructPanicsOnMalformedTag(t *testing.T) {
+	tests := []struct {
+		name string
+		v    any
+	}{
+		{name: "unknown rule", v: struct {
+			A string `validate:"maxlen=3"`
+		}{}},
+		{name: "invalid number", v: struct {
+			A string `validate:"max=three"`
+		}{A: "a"}},
+		{name: "invalid date", v: struct {
+			A time.Time `validate:"after=yesterday"`
+		}{}},
+		{name: "oneof on number", v: struct {
+			A int `validate:"oneof=1 2"`
+		}{}},
+		{name: "itemmax on string", v: struct {
+			A string `validate:"itemmax=3"`
+		}{}},
+		{name: "after on string", v: struct {
+			A string `validate:"after=2025-01-01"`
+		}{}},
+		{name: "max on bool", v: struct {
+			A bool `validate:"max=1"`
+		}{}},
+		{name: "not a struct", v: "title"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Error("Struct() did not panic")
+				}
+			}()
+			Struct(tt.v)
+		})
+	}
+}